@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"cove/pkg/cove"
+)
+
+// Exit codes for subcommands, so scripts can tell failures apart
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+	exitConflict = 4
+)
+
+// cliError carries the exit code a failed subcommand should end with
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+func (e *cliError) Unwrap() error { return e.err }
+
+func usageErrorf(format string, args ...any) error {
+	return &cliError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// exitCode maps an error returned by a subcommand to the process exit code
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	var cliErr *cliError
+	if errors.As(err, &cliErr) {
+		return cliErr.code
+	}
+	switch {
+	case errors.Is(err, cove.ErrTodoNotFound):
+		return exitNotFound
+	case errors.Is(err, cove.ErrAmbiguousTodo), errors.Is(err, cove.ErrTodoDone),
+		errors.Is(err, cove.ErrAlreadyRunning), errors.Is(err, cove.ErrNotRunning):
+		return exitConflict
+	}
+	return exitError
+}
+
+// parseArgs parses flags that may appear before, between or after
+// positional arguments, returning the positional ones
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &cliError{code: exitUsage, err: err}
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// controller talks to the daemon when one is running and otherwise drives
+// the timer in-process using the given state and history files
+func controller(statePath, historyPath string) cove.Controller {
+	if client, err := cove.DialDaemon(cove.DefaultSocketPath()); err == nil {
+		return client
+	}
+	return newEngine(statePath, historyPath)
+}
+
+// newEngine runs the timer in this process, with the hooks and autosaving
+// from the config
+func newEngine(statePath, historyPath string) *cove.Engine {
+	return cove.NewEngine(statePath, historyPath).
+		WithHooks(hooks()).
+		WithAutosave(config.Tracking.Autosave.Duration).
+		WithEstimates(config.Estimates())
+}
+
+// hooks runs the hook commands from the config, showing their output on
+// stderr
+func hooks() *cove.Hooks {
+	h := cove.NewHooks(config.Hooks)
+	h.Output = os.Stderr
+	return h
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
+
+// writeJSONLine writes v compactly on one line, as status bars expect
+func writeJSONLine(v any) error {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
+
+func absFile(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		return abs
+	}
+	return filename
+}
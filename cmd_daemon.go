@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"cove/pkg/cove"
+)
+
+func runDaemon(args []string) error {
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	socketPath := flags.String("socket", cove.DefaultSocketPath(), "Unix socket to listen on")
+	statePath := flags.String("state", cove.DefaultStatePath(), "active session state file")
+	historyPath := flags.String("history", config.History, "session history file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usageErrorf("daemon takes no arguments")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	daemon := cove.NewDaemon(newEngine(*statePath, *historyPath), *socketPath)
+	fmt.Fprintf(os.Stderr, "cove daemon listening on %s\n", *socketPath)
+	return daemon.ListenAndServe(ctx)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"cove/pkg/cove"
+)
+
+func runEstimates(args []string) error {
+	flags := flag.NewFlagSet("estimates", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the report or suggestion as JSON")
+	period := flags.String("period", cove.PeriodWeek, "group completed todos over time by week or month")
+	suggest := flags.String("suggest", "", "suggest an estimate for a new todo described like this")
+	historyPath := flags.String("history", config.History, "session history file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return usageErrorf("estimates needs at least one markdown file")
+	}
+	if *period != cove.PeriodWeek && *period != cove.PeriodMonth {
+		return usageErrorf("unknown period %q (want week or month)", *period)
+	}
+
+	sessions, err := cove.ReadSessions(*historyPath)
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+
+	var samples []cove.EstimateSample
+	for _, filename := range positional {
+		todos, err := cove.ReadTodosWithEstimates(filename, config.Estimates())
+		if err != nil {
+			return fmt.Errorf("reading todos: %w", err)
+		}
+		samples = append(samples, cove.EstimateSamples(filename, todos, sessions)...)
+	}
+
+	if *suggest != "" {
+		return printSuggestion(samples, *suggest, *asJSON)
+	}
+
+	report := cove.NewAccuracyReport(samples, *period)
+	if *asJSON {
+		return writeJSON(os.Stdout, cove.NewAccuracyReportRecord(report))
+	}
+	if report.Overall.Todos == 0 {
+		fmt.Println("No finished todos with an estimate and time spent yet.")
+		return nil
+	}
+	printAccuracy("", []cove.Accuracy{report.Overall})
+	printAccuracy("Person", report.People)
+	printAccuracy("Tag", report.Tags)
+	printAccuracy("Section", report.Sections)
+	printAccuracy(strings.ToUpper((*period)[:1])+(*period)[1:], report.Periods)
+	return nil
+}
+
+// printAccuracy prints one table of the report, skipping empty ones
+func printAccuracy(title string, groups []cove.Accuracy) {
+	if len(groups) == 0 {
+		return
+	}
+	if title != "" {
+		fmt.Printf("\n%s\n", title)
+	}
+	for _, a := range groups {
+		fmt.Printf("  %-24s %5.2fx  %3d todos  %3d over  (%v of %v)\n",
+			a.Key, a.Ratio(), a.Todos, a.Over, a.Spent.Round(time.Minute), a.Estimated)
+	}
+}
+
+func printSuggestion(samples []cove.EstimateSample, description string, asJSON bool) error {
+	suggestion, ok := cove.SuggestEstimate(samples, description, config.Estimates())
+	if !ok {
+		return &cliError{code: exitNotFound, err: fmt.Errorf("no finished todos like %q", description)}
+	}
+	if asJSON {
+		similar := make([]cove.TodoRecord, 0, len(suggestion.Similar))
+		for _, sample := range suggestion.Similar {
+			similar = append(similar, cove.NewTodoRecord(sample.Todo, sample.File))
+		}
+		return writeJSON(os.Stdout, struct {
+			EstimateSeconds int64             `json:"estimate_seconds"`
+			Similar         []cove.TodoRecord `json:"similar"`
+		}{int64(suggestion.Estimate.Seconds()), similar})
+	}
+
+	fmt.Printf("Suggested estimate: %v (%s)\n", suggestion.Estimate, strings.Repeat("*", config.Estimates().Stars(suggestion.Estimate)))
+	fmt.Println("\nBased on:")
+	for _, sample := range suggestion.Similar {
+		fmt.Printf("  %v of %v  %s\n", sample.Todo.TimeSpent.Round(time.Minute), sample.Todo.EstimatedTime, sample.Todo.Description)
+	}
+	return nil
+}
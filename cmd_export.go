@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"cove/pkg/cove"
+)
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "csv", "output format: csv, json, ics, taskwarrior or timewarrior")
+	sessionsOnly := flags.Bool("sessions", false, "with csv, export session history instead of todos")
+	historyPath := flags.String("history", config.History, "session history file")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("export needs exactly one markdown file")
+	}
+	filename := positional[0]
+
+	todos, err := cove.ReadTodosWithEstimates(filename, config.Estimates())
+	if err != nil {
+		return fmt.Errorf("reading todos: %w", err)
+	}
+
+	sessions, err := cove.ReadSessions(*historyPath)
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+	sessions = cove.SessionsForFile(sessions, filename)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "json":
+		return cove.ExportJSON(w, filename, todos, sessions)
+	case "csv":
+		if *sessionsOnly {
+			return cove.ExportSessionsCSV(w, sessions)
+		}
+		return cove.ExportTodosCSV(w, filename, todos)
+	case "ics":
+		return cove.ExportICalendar(w, filename, todos, sessions)
+	case "taskwarrior":
+		return writeJSON(w, cove.ToTaskwarrior(filename, todos, sessions))
+	case "timewarrior":
+		return writeJSON(w, cove.ToTimewarrior(sessions))
+	default:
+		return usageErrorf("unknown export format %q (want csv, json, ics, taskwarrior or timewarrior)", *format)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cove/pkg/cove"
+)
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "taskwarrior", "input format: taskwarrior or timewarrior")
+	historyPath := flags.String("history", config.History, "session history file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("import needs an input file (or - for stdin) and a markdown file")
+	}
+	input, filename := positional[0], positional[1]
+
+	var r io.Reader = os.Stdin
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return fmt.Errorf("failed to open input: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	switch *format {
+	case "taskwarrior":
+		var tasks []cove.TaskwarriorTask
+		if err := cove.DecodeWarriorJSON(r, &tasks); err != nil {
+			return err
+		}
+		return importTodos(filename, cove.FromTaskwarrior(tasks, config.Estimates()))
+	case "timewarrior":
+		var intervals []cove.TimewarriorInterval
+		if err := cove.DecodeWarriorJSON(r, &intervals); err != nil {
+			return err
+		}
+		return importSessions(*historyPath, cove.FromTimewarrior(intervals, filename))
+	default:
+		return usageErrorf("unknown import format %q (want taskwarrior or timewarrior)", *format)
+	}
+}
+
+// importTodos appends todos that are not already in the file
+func importTodos(filename string, imported []cove.Todo) error {
+	existing, err := cove.ReadTodosWithEstimates(filename, config.Estimates())
+	if err != nil {
+		return fmt.Errorf("reading todos: %w", err)
+	}
+
+	var todos []cove.Todo
+	for _, todo := range imported {
+		duplicate := false
+		for _, other := range existing {
+			if sameDescription(other.Description, todo.Description) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			todos = append(todos, todo)
+		}
+	}
+
+	if err := cove.AppendTodos(filename, todos); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d todos (%d already present)\n", len(todos), len(imported)-len(todos))
+	return nil
+}
+
+// sameDescription compares descriptions ignoring case and spacing, since
+// removing star hints can leave extra spaces behind
+func sameDescription(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+// importSessions appends sessions that are not already in the history
+func importSessions(historyPath string, imported []cove.Session) error {
+	existing, err := cove.ReadSessions(historyPath)
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+
+	count := 0
+	for _, session := range imported {
+		duplicate := false
+		for _, other := range existing {
+			if other.Start.Equal(session.Start) && other.Description == session.Description {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		if err := cove.AppendSession(historyPath, session); err != nil {
+			return err
+		}
+		count++
+	}
+
+	fmt.Printf("Imported %d sessions (%d already present)\n", count, len(imported)-count)
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cove/pkg/cove"
+)
+
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:7878", "address to listen on")
+	statePath := flags.String("state", cove.DefaultStatePath(), "active session state file")
+	historyPath := flags.String("history", config.History, "session history file")
+	allowRemote := flags.Bool("allow-remote", false, "listen on a non-loopback address; anyone who can reach it controls the timer")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("serve needs exactly one markdown file")
+	}
+	filename := absFile(positional[0])
+
+	// The API has no authentication, so only this machine may reach it
+	// unless the user says otherwise
+	host, _, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return usageErrorf("invalid --addr %q: %v", *addr, err)
+	}
+	if !cove.IsLoopbackHost(host) {
+		if !*allowRemote {
+			return usageErrorf("%s is not a loopback address and the API has no authentication; pass --allow-remote to listen on it anyway", *addr)
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: listening on %s without authentication; anyone who can reach it can read your todos and control the timer\n", *addr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	timer := controller(*statePath, *historyPath)
+	if engine, ok := timer.(*cove.Engine); ok {
+		// Without a daemon this process owns the timer, so it reports timeouts
+		go engine.Watch(ctx)
+	}
+
+	server := cove.NewServer(timer, filename, *addr).WithEstimates(config.Estimates())
+	watcher, err := server.Watch()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "cove serving %s on http://%s\n", filename, *addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
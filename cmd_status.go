@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"cove/pkg/cove"
+)
+
+func runStatus(args []string) error {
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	output := flags.String("output", "text", "output style: text, json, i3bar or waybar")
+	text := flags.String("template", cove.DefaultStatusTemplate, "Go text/template for the status text")
+	watch := flags.Bool("watch", false, "print a new status line every second")
+	statePath := flags.String("state", cove.DefaultStatePath(), "active session state file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usageErrorf("status takes no arguments")
+	}
+
+	switch *output {
+	case "text", "json", "i3bar", "waybar":
+	default:
+		return usageErrorf("unknown status output %q (want text, json, i3bar or waybar)", *output)
+	}
+
+	timer := controller(*statePath, config.History)
+	// Watched, i3bar expects its protocol rather than one block per line
+	var stream *i3barStream
+	if *watch && *output == "i3bar" {
+		stream = &i3barStream{}
+	}
+	for {
+		if err := printStatus(timer, *output, *text, stream); err != nil {
+			return err
+		}
+		if !*watch {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func printStatus(timer cove.Controller, output, text string, stream *i3barStream) error {
+	active, err := timer.Status()
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		if active == nil {
+			_, err := fmt.Println("null")
+			return err
+		}
+		return writeJSON(os.Stdout, cove.NewActiveRecord(active))
+	}
+
+	status := cove.NewStatus(active)
+	line, err := status.Render(text)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	switch output {
+	case "i3bar":
+		if stream != nil {
+			return stream.write(status.I3Block(line))
+		}
+		return writeJSONLine(status.I3Block(line))
+	case "waybar":
+		return writeJSONLine(status.Waybar(line))
+	}
+	_, err = fmt.Println(line)
+	return err
+}
+
+// i3barStream writes blocks in the i3bar protocol: a header, then an
+// endless JSON array holding one array of blocks per update
+type i3barStream struct {
+	started bool
+}
+
+func (s *i3barStream) write(block cove.I3Block) error {
+	prefix := ","
+	if !s.started {
+		prefix = "{\"version\":1}\n[\n"
+		s.started = true
+	}
+	if _, err := fmt.Print(prefix); err != nil {
+		return err
+	}
+	return writeJSONLine([]cove.I3Block{block})
+}
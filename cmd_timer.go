@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cove/pkg/cove"
+)
+
+func runStart(args []string) error {
+	flags := flag.NewFlagSet("start", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the started session as JSON")
+	wait := flags.Bool("wait", false, "stay in the foreground until the estimate runs out")
+	switchTodo := flags.Bool("switch", false, "record the running session and start this todo instead")
+	statePath := flags.String("state", cove.DefaultStatePath(), "active session state file")
+	historyPath := flags.String("history", config.History, "session history file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("start needs a markdown file and a line number or pattern")
+	}
+	filename, selector := positional[0], positional[1]
+
+	timer := controller(*statePath, *historyPath)
+	var active *cove.ActiveSession
+	if *switchTodo {
+		active, err = timer.Switch(filename, selector)
+	} else {
+		active, err = timer.Start(filename, selector)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if err := writeJSON(os.Stdout, cove.NewActiveRecord(active)); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Started %q (%v)\n", active.Description, active.Estimate)
+	}
+
+	if *wait {
+		if engine, ok := timer.(*cove.Engine); ok {
+			// Without a daemon this process owns the timer, so it autosaves
+			// and reports timeouts
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go engine.Watch(ctx)
+		}
+		return followActive(timer)
+	}
+	return nil
+}
+
+func runPause(args []string) error {
+	flags := flag.NewFlagSet("pause", flag.ContinueOnError)
+	toggle := flags.Bool("toggle", false, "resume instead if already paused")
+	statePath := flags.String("state", cove.DefaultStatePath(), "active session state file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usageErrorf("pause takes no arguments")
+	}
+
+	timer := controller(*statePath, config.History)
+	if *toggle {
+		active, err := timer.Status()
+		if err != nil {
+			return err
+		}
+		if active != nil && active.Paused {
+			_, err = timer.Resume()
+			return err
+		}
+	}
+	_, err = timer.Pause()
+	return err
+}
+
+func runResume(args []string) error {
+	flags := flag.NewFlagSet("resume", flag.ContinueOnError)
+	statePath := flags.String("state", cove.DefaultStatePath(), "active session state file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usageErrorf("resume takes no arguments")
+	}
+
+	_, err = controller(*statePath, config.History).Resume()
+	return err
+}
+
+func runStop(args []string) error {
+	flags := flag.NewFlagSet("stop", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the recorded session as JSON")
+	markDone := flags.Bool("done", false, "also mark the todo as done")
+	discard := flags.Bool("discard", false, "drop the session without recording any time")
+	statePath := flags.String("state", cove.DefaultStatePath(), "active session state file")
+	historyPath := flags.String("history", config.History, "session history file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usageErrorf("stop takes no arguments")
+	}
+
+	timer := controller(*statePath, *historyPath)
+	if *discard {
+		active, err := timer.Discard()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Discarded %q\n", active.Description)
+		return nil
+	}
+
+	session, err := timer.Stop(*markDone)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(os.Stdout, cove.NewSessionRecord(session))
+	}
+	fmt.Printf("Stopped %q after %v\n", session.Description, session.Duration.Round(time.Second))
+	return nil
+}
+
+// followActive keeps the running session in the foreground, showing the
+// countdown on stderr. The session is recorded when the estimate runs out
+// or on Ctrl+C, and following ends if it is stopped from elsewhere. A
+// stopwatch session has no estimate to run out, so only Ctrl+C or `cove
+// stop` end it.
+func followActive(timer cove.Controller) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		active, err := timer.Status()
+		if err != nil {
+			return err
+		}
+		if active == nil {
+			fmt.Fprintln(os.Stderr)
+			return nil
+		}
+
+		line, err := cove.NewStatus(active).Render(cove.DefaultStatusTemplate)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+
+		timedOut := !active.Paused && active.Estimate > 0 && active.Remaining() == 0
+		if !timedOut {
+			select {
+			case <-interrupt:
+			case <-ticker.C:
+				continue
+			}
+		}
+
+		session, err := timer.Stop(false)
+		if err != nil {
+			return err
+		}
+		if timedOut {
+			// Ring the terminal bell so a hidden terminal still gets noticed
+			fmt.Fprint(os.Stderr, "\a")
+		}
+		fmt.Fprintf(os.Stderr, "\nStopped %q after %v\n", session.Description, session.Duration.Round(time.Second))
+		return nil
+	}
+}
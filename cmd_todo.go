@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"cove/pkg/cove"
+)
+
+func runList(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print todos as JSON")
+	openOnly := flags.Bool("open", false, "only list todos that are not done")
+	filterQuery := flags.String("filter", "", "only list todos matching a saved filter or a query like tag:bug")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("list needs exactly one markdown file")
+	}
+	filename := positional[0]
+
+	todos, err := cove.ReadTodosWithEstimates(filename, config.Estimates())
+	if err != nil {
+		return fmt.Errorf("reading todos: %w", err)
+	}
+
+	if *openOnly {
+		var open []cove.Todo
+		for _, todo := range todos {
+			if todo.State == cove.Open {
+				open = append(open, todo)
+			}
+		}
+		todos = open
+	}
+
+	if *filterQuery != "" {
+		query := *filterQuery
+		if saved, ok := config.Filters[query]; ok {
+			query = saved
+		}
+		filter, err := cove.ParseFilter(query)
+		if err != nil {
+			return usageErrorf("filter: %v", err)
+		}
+		todos = cove.FilterTodos(todos, filter)
+	}
+
+	if *asJSON {
+		records := make([]cove.TodoRecord, 0, len(todos))
+		for _, todo := range todos {
+			records = append(records, cove.NewTodoRecord(todo, filename))
+		}
+		return writeJSON(os.Stdout, records)
+	}
+
+	for _, todo := range todos {
+		fmt.Println(formatTodo(todo))
+	}
+	return nil
+}
+
+func runAdd(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the new todo as JSON")
+	estimate := flags.Duration("est", config.Durations.Default.Duration, "estimate, rounded up to whole star hints")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return usageErrorf("add needs a markdown file and a description")
+	}
+	filename := positional[0]
+	description := strings.TrimSpace(strings.Join(positional[1:], " "))
+	if description == "" || strings.ContainsAny(description, "\r\n") {
+		return usageErrorf("the description must be one non-empty line")
+	}
+	if *estimate <= 0 {
+		return usageErrorf("estimate must be positive")
+	}
+
+	// Estimates are written as star hints, so round up to whole stars
+	todo := cove.NewTodo(description)
+	todo.SetEstimate(*estimate, config.Estimates())
+	if err := cove.AppendTodos(filename, []cove.Todo{todo}); err != nil {
+		return err
+	}
+
+	// Read the file back so the new todo has its line number and tags. It
+	// is the last line, since appending always ends with a newline.
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("reading todos: %w", err)
+	}
+	todos, err := cove.ReadTodosWithEstimates(filename, config.Estimates())
+	if err != nil {
+		return fmt.Errorf("reading todos: %w", err)
+	}
+	line := strings.Count(string(content), "\n")
+	index := slices.IndexFunc(todos, func(todo cove.Todo) bool { return todo.LineNumber == line })
+	if index < 0 {
+		return fmt.Errorf("the new todo on line %d of %s can't be read back as a todo", line, filename)
+	}
+	added := todos[index]
+
+	if *asJSON {
+		return writeJSON(os.Stdout, cove.NewTodoRecord(added, filename))
+	}
+	fmt.Println(formatTodo(added))
+	return nil
+}
+
+func runDone(args []string) error {
+	flags := flag.NewFlagSet("done", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the finished todo as JSON")
+	statePath := flags.String("state", cove.DefaultStatePath(), "active session state file")
+	historyPath := flags.String("history", config.History, "session history file")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageErrorf("done needs a markdown file and a line number or pattern")
+	}
+	filename, selector := positional[0], positional[1]
+
+	todo, err := controller(*statePath, *historyPath).Done(filename, selector)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(os.Stdout, cove.NewTodoRecord(todo, filename))
+	}
+	fmt.Println(formatTodo(todo))
+	return nil
+}
+
+// formatTodo renders a todo as a single line for terminal output
+func formatTodo(todo cove.Todo) string {
+	checkbox := "[ ]"
+	if todo.State == cove.Done {
+		checkbox = "[x]"
+	} else if todo.TimeSpent > 0 {
+		checkbox = "[*]"
+	}
+
+	line := fmt.Sprintf("%4d %s %s", todo.LineNumber, checkbox, todo.Description)
+	if todo.TimeSpent > 0 {
+		line += fmt.Sprintf("  (%v of %v)", todo.TimeSpent.Round(time.Minute), todo.EstimatedTime)
+	} else {
+		line += fmt.Sprintf("  (%v)", todo.EstimatedTime)
+	}
+	return line
+}
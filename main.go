@@ -1,30 +1,116 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"cove/pkg/cove"
 )
 
+// commands maps subcommand names to their handlers; anything else is
+// treated as a markdown file to open in the TUI
+var commands = map[string]func(args []string) error{
+	"list":   runList,
+	"add":    runAdd,
+	"done":   runDone,
+	"start":  runStart,
+	"stop":   runStop,
+	"pause":  runPause,
+	"resume": runResume,
+	"status": runStatus,
+	"daemon": runDaemon,
+	"serve":  runServe,
+	"export": runExport,
+	"import": runImport,
+
+	"estimates": runEstimates,
+}
+
+// config is loaded before any command runs; its history file is the default
+// for every --history flag
+var config = cove.DefaultConfig()
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [--profile name] [--config path] <markdown-file|command> ...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s <markdown-file>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s list [--open] [--filter name|query] [--json] <markdown-file>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s add [--est 25m] [--json] <markdown-file> <description>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s done [--json] <markdown-file> <line|pattern>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s start [--switch] [--wait] [--json] <markdown-file> <line|pattern>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s pause [--toggle] | resume\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s stop [--done|--discard] [--json]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s status [--output text|json|i3bar|waybar] [--template tmpl] [--watch]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s daemon [--socket path]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s serve [--addr 127.0.0.1:7878] <markdown-file>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s export [--format csv|json|ics|taskwarrior|timewarrior] [--sessions] [-o file] <markdown-file>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s import [--format taskwarrior|timewarrior] <input|-> <markdown-file>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s estimates [--period week|month] [--suggest description] [--json] <markdown-file>...\n", os.Args[0])
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <markdown-file>\n", os.Args[0])
+	// Global options come before the command or file
+	flags := flag.NewFlagSet("cove", flag.ContinueOnError)
+	flags.Usage = usage
+	configPath := flags.String("config", cove.DefaultConfigPath(), "config file")
+	profile := flags.String("profile", os.Getenv("COVE_PROFILE"), "config profile to use")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(exitOK)
+		}
+		os.Exit(exitUsage)
+	}
+	args := flags.Args()
+	if len(args) < 1 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	loaded, err := cove.LoadConfig(*configPath, *profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in config: %v\n", err)
 		os.Exit(1)
 	}
+	config = loaded
+
+	if command, ok := commands[args[0]]; ok {
+		err := command(args[1:])
+		code := exitCode(err)
+		if code != exitOK {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(code)
+	}
 
-	filename := os.Args[1]
+	filename := args[0]
 	
-	todos, err := cove.ReadTodos(filename)
+	todos, err := cove.ReadTodosWithEstimates(filename, config.Estimates())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading todos: %v\n", err)
 		os.Exit(1)
 	}
 
-	model := cove.NewTodoSelector(todos, filename)
+	terminal := cove.NewTerminal(os.Stdout)
+	model := cove.NewTodoSelector(todos, filename).WithConfig(config).WithTerminal(terminal)
+	if daemon, err := cove.DialDaemon(cove.DefaultSocketPath()); err == nil {
+		model = model.WithDaemon(daemon)
+	}
+	
+	// Signals are turned into a message so the running session is recorded
+	// before exiting, even when the terminal goes away
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithoutSignalHandler(), tea.WithOutput(terminal))
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			p.Send(cove.ShutdownMsg{})
+		}
+	}()
 	
-	p := tea.NewProgram(model, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,184 @@
+package cove
+
+import (
+	"sync"
+	"time"
+)
+
+// BusEvent is a typed event published on an EventBus. Subscribers tell the
+// events apart with a type switch:
+//
+//	bus.Subscribe(cove.SubscriberFunc(func(event cove.BusEvent) {
+//		switch e := event.(type) {
+//		case cove.SessionCompleted:
+//			log.Printf("worked %v on %q", e.Session.Duration, e.Session.Description)
+//		case cove.TodoChanged:
+//			log.Printf("%s:%d changed", e.File, e.Todo.LineNumber)
+//		}
+//	}))
+type BusEvent interface {
+	EventTime() time.Time
+}
+
+// SessionStarted is published when a todo's timer starts
+type SessionStarted struct {
+	Time    time.Time
+	Session ActiveSession
+}
+
+// SessionPaused is published when the running timer is paused
+type SessionPaused struct {
+	Time    time.Time
+	Session ActiveSession
+}
+
+// SessionResumed is published when a paused timer starts again
+type SessionResumed struct {
+	Time    time.Time
+	Session ActiveSession
+}
+
+// SessionTimedOut is published when the running session reaches its
+// estimate. The session keeps going until it is stopped.
+type SessionTimedOut struct {
+	Time    time.Time
+	Session ActiveSession
+}
+
+// SessionCompleted is published when a session ends and its time has been
+// recorded. Session.Completed says whether the todo was marked done too.
+type SessionCompleted struct {
+	Time    time.Time
+	Session Session
+}
+
+// SessionDiscarded is published when a session ends without recording time
+type SessionDiscarded struct {
+	Time    time.Time
+	Session ActiveSession
+}
+
+// TodoChanged is published after a todo is written back to its markdown
+// file, with time added or marked done
+type TodoChanged struct {
+	Time time.Time
+	File string
+	Todo Todo
+}
+
+// FileReloaded is published when the TUI re-reads a markdown file that was
+// changed outside of it
+type FileReloaded struct {
+	Time  time.Time
+	File  string
+	Todos []Todo
+}
+
+func (e SessionStarted) EventTime() time.Time   { return e.Time }
+func (e SessionPaused) EventTime() time.Time    { return e.Time }
+func (e SessionResumed) EventTime() time.Time   { return e.Time }
+func (e SessionTimedOut) EventTime() time.Time  { return e.Time }
+func (e SessionCompleted) EventTime() time.Time { return e.Time }
+func (e SessionDiscarded) EventTime() time.Time { return e.Time }
+func (e TodoChanged) EventTime() time.Time      { return e.Time }
+func (e FileReloaded) EventTime() time.Time     { return e.Time }
+
+// Subscriber receives the events published on an EventBus
+type Subscriber interface {
+	HandleEvent(event BusEvent)
+}
+
+// SubscriberFunc lets a plain function be a Subscriber
+type SubscriberFunc func(event BusEvent)
+
+func (f SubscriberFunc) HandleEvent(event BusEvent) { f(event) }
+
+// EventBus hands events from an Engine or the TUI to in-process
+// subscribers, for programs that embed cove. Events are delivered
+// synchronously and in order, so subscribers should return quickly and
+// must not call back into the Engine that published the event; hand slow
+// work off to a goroutine.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers []subscription
+	nextID      int
+}
+
+type subscription struct {
+	id         int
+	subscriber Subscriber
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe adds a subscriber and returns a function that removes it again
+func (b *EventBus) Subscribe(subscriber Subscriber) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.subscribers = append(b.subscribers, subscription{id: id, subscriber: subscriber})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			for i, s := range b.subscribers {
+				if s.id == id {
+					b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// Publish delivers event to every subscriber. A nil bus drops it.
+func (b *EventBus) Publish(event BusEvent) {
+	if b == nil {
+		return
+	}
+	// Subscribers are called without the lock held so they can subscribe
+	// or unsubscribe from inside HandleEvent
+	b.mu.Lock()
+	subscribers := b.subscribers
+	b.mu.Unlock()
+
+	for _, s := range subscribers {
+		s.subscriber.HandleEvent(event)
+	}
+}
+
+// publishSession publishes the typed form of a session event. Event types
+// without one, like switched, which follows its own stopped and started
+// events, are skipped.
+func (b *EventBus) publishSession(event Event) {
+	if b == nil {
+		return
+	}
+	switch {
+	case event.Active != nil:
+		active := *event.Active
+		switch event.Type {
+		case EventStarted:
+			b.Publish(SessionStarted{Time: event.Time, Session: active})
+		case EventPaused:
+			b.Publish(SessionPaused{Time: event.Time, Session: active})
+		case EventResumed:
+			b.Publish(SessionResumed{Time: event.Time, Session: active})
+		case EventTimeout:
+			b.Publish(SessionTimedOut{Time: event.Time, Session: active})
+		case EventDiscarded:
+			b.Publish(SessionDiscarded{Time: event.Time, Session: active})
+		}
+	case event.Session != nil:
+		switch event.Type {
+		case EventStopped, EventDone:
+			b.Publish(SessionCompleted{Time: event.Time, Session: *event.Session})
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package cove
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Client controls a running `cove daemon` over its Unix socket
+type Client struct {
+	socketPath string
+}
+
+// DialDaemon returns a client if a daemon is listening on socketPath
+func DialDaemon(socketPath string) (*Client, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("no daemon running: %w", err)
+	}
+	conn.Close()
+	return &Client{socketPath: socketPath}, nil
+}
+
+func (c *Client) Status() (*ActiveSession, error) {
+	resp, err := c.call(Request{Command: "status"})
+	return resp.Active, err
+}
+
+func (c *Client) Start(filename, todo string) (*ActiveSession, error) {
+	resp, err := c.call(Request{Command: "start", File: absPath(filename), Todo: todo})
+	return resp.Active, err
+}
+
+func (c *Client) Switch(filename, todo string) (*ActiveSession, error) {
+	resp, err := c.call(Request{Command: "switch", File: absPath(filename), Todo: todo})
+	return resp.Active, err
+}
+
+func (c *Client) Pause() (*ActiveSession, error) {
+	resp, err := c.call(Request{Command: "pause"})
+	return resp.Active, err
+}
+
+func (c *Client) Resume() (*ActiveSession, error) {
+	resp, err := c.call(Request{Command: "resume"})
+	return resp.Active, err
+}
+
+func (c *Client) Stop(markDone bool) (Session, error) {
+	resp, err := c.call(Request{Command: "stop", MarkDone: markDone})
+	if err != nil {
+		return Session{}, err
+	}
+	return *resp.Session, nil
+}
+
+func (c *Client) Discard() (*ActiveSession, error) {
+	resp, err := c.call(Request{Command: "discard"})
+	return resp.Active, err
+}
+
+func (c *Client) Done(filename, todo string) (Todo, error) {
+	resp, err := c.call(Request{Command: "done", File: absPath(filename), Todo: todo})
+	if err != nil {
+		return Todo{}, err
+	}
+	return *resp.Todo, nil
+}
+
+// Subscribe streams the daemon's events until the returned cancel function
+// is called or the daemon goes away, at which point the channel is closed
+func (c *Client) Subscribe() (<-chan Event, func(), error) {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	if err := json.NewEncoder(conn).Encode(Request{Command: "subscribe"}); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	var resp Response
+	if err := decodeLine(reader, &resp); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if !resp.OK {
+		conn.Close()
+		return nil, nil, responseError(resp)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for {
+			var event Event
+			if err := decodeLine(reader, &event); err != nil {
+				return
+			}
+			events <- event
+		}
+	}()
+
+	cancel := func() {
+		conn.Close()
+		// Drain so the reader goroutine can see the closed connection and exit
+		for range events {
+		}
+	}
+	return events, cancel, nil
+}
+
+func (c *Client) call(req Request) (Response, error) {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := decodeLine(bufio.NewReader(conn), &resp); err != nil {
+		return Response{}, err
+	}
+	if !resp.OK {
+		return resp, responseError(resp)
+	}
+	return resp, nil
+}
+
+func decodeLine(reader *bufio.Reader, v any) error {
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read from daemon: %w", err)
+	}
+	if err := json.Unmarshal(line, v); err != nil {
+		return fmt.Errorf("invalid reply from daemon: %w", err)
+	}
+	return nil
+}
+
+// responseError rebuilds an error that matches the engine's sentinel errors
+func responseError(resp Response) error {
+	if kind, ok := errorCodes[resp.Code]; ok {
+		return &engineError{kind: kind, msg: resp.Error}
+	}
+	return errors.New(resp.Error)
+}
@@ -0,0 +1,426 @@
+package cove
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds the user's settings from config.toml. Every setting can also
+// be given in a [profiles.NAME] table, which overrides the top level when
+// that profile is selected.
+type Config struct {
+	Durations DurationConfig       `toml:"durations"`
+	Pomodoro  PomodoroConfig       `toml:"pomodoro"`
+	Notify    NotifyConfig         `toml:"notify"`
+	Tracking  TrackingConfig       `toml:"tracking"`
+	Keys      map[string][]string  `toml:"keys"`
+	Theme     ThemeConfig          `toml:"theme"`
+	Themes    map[string]UserTheme `toml:"themes"`
+	// History is the session history file; "~/" is expanded
+	History string `toml:"history"`
+	// Hooks maps event types to shell commands run when they happen
+	Hooks map[string]string `toml:"hooks"`
+	// Goal is a daily focus target, off unless set
+	Goal GoalConfig `toml:"goal"`
+	// Filters are saved filter queries the selector can cycle through
+	Filters map[string]string `toml:"filters"`
+}
+
+type DurationConfig struct {
+	// Star is the estimate each "*" in a todo adds
+	Star Duration `toml:"star"`
+	// Default is the estimate of a todo without stars
+	Default Duration `toml:"default"`
+	// Poll is how often the TUI checks the markdown file for changes
+	Poll Duration `toml:"poll"`
+}
+
+// PomodoroConfig sets up breaks between work intervals. A work interval is
+// the todo's estimate.
+type PomodoroConfig struct {
+	// Enabled starts a break when a work interval runs out, instead of
+	// offering to extend it
+	Enabled    bool     `toml:"enabled"`
+	ShortBreak Duration `toml:"short_break"`
+	LongBreak  Duration `toml:"long_break"`
+	// LongBreakEvery is how many work intervals make a cycle
+	LongBreakEvery int `toml:"long_break_every"`
+	// AutoStartWork starts the next work interval on the same todo as soon
+	// as a break ends
+	AutoStartWork bool `toml:"auto_start_work"`
+	// BreakMode decides whether breaks can be cut short: always, only
+	// after SkipAfter, or never
+	BreakMode string   `toml:"break_mode"`
+	SkipAfter Duration `toml:"skip_after"`
+	// Suggestions are shown on the break screen, one per break in turn
+	Suggestions []string `toml:"suggestions"`
+}
+
+// TrackingConfig is the [tracking] table, about how time gets recorded
+type TrackingConfig struct {
+	// ConfirmQuit asks before quitting from a running timer. The time is
+	// recorded either way; this only guards against quitting by mistake.
+	ConfirmQuit bool `toml:"confirm_quit"`
+	// Autosave writes the time worked so far into the markdown file after
+	// every interval of work, instead of only when the session ends; 0
+	// turns it off
+	Autosave Duration `toml:"autosave"`
+	// Overtime keeps counting when the estimate runs out, until the todo
+	// is switched, finished or extended. Without it the session pauses
+	// until the user answers. Pomodoro breaks start right away either way.
+	Overtime bool `toml:"overtime"`
+}
+
+// GoalConfig is the [goal] table: a daily target of either pomodoros or
+// hours of focus, counted from the session history
+type GoalConfig struct {
+	// Pomodoros counts the sessions that ran for their full estimate
+	Pomodoros int `toml:"pomodoros"`
+	// Focus is the time worked, including time past the estimate
+	Focus Duration `toml:"focus"`
+}
+
+// Break modes
+const (
+	BreakSkippable = "skippable"
+	BreakDelayed   = "delayed"
+	BreakStrict    = "strict"
+)
+
+var breakModes = []string{BreakSkippable, BreakDelayed, BreakStrict}
+
+// Duration reads Go duration strings like "25m" from the config file
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	d.Duration = duration
+	return nil
+}
+
+// configFile is the layout of config.toml
+type configFile struct {
+	Config
+	// Profile is used when none is given on the command line
+	Profile  string                    `toml:"profile"`
+	Profiles map[string]toml.Primitive `toml:"profiles"`
+}
+
+// Key actions, each bound to one or more keys in the [keys] table
+var keyActions = []string{"up", "down", "start", "quit", "pause", "switch", "done", "yes", "no", "skip", "help", "resume", "commit", "discard", "stopwatch", "search", "filter"}
+
+// Event types that can have a hook
+var hookEvents = []string{EventStarted, EventPaused, EventResumed, EventStopped, EventSwitched, EventDone, EventDiscarded, EventTimeout, EventReloaded}
+
+var colorRegex = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Estimates returns the star and default estimates of todos
+func (c Config) Estimates() Estimates {
+	return Estimates{Star: c.Durations.Star.Duration, Default: c.Durations.Default.Duration}
+}
+
+// DefaultConfig is used for anything the config file leaves out
+func DefaultConfig() Config {
+	return Config{
+		Durations: DurationConfig{
+			Star:    Duration{5 * time.Minute},
+			Default: Duration{20 * time.Minute},
+			Poll:    Duration{2 * time.Second},
+		},
+		Pomodoro: PomodoroConfig{
+			ShortBreak:     Duration{5 * time.Minute},
+			LongBreak:      Duration{15 * time.Minute},
+			LongBreakEvery: 4,
+			BreakMode:      BreakSkippable,
+			SkipAfter:      Duration{time.Minute},
+			Suggestions: []string{
+				"Stand up and stretch.",
+				"Look at something far away for a bit.",
+				"Get a glass of water.",
+				"Take a few slow, deep breaths.",
+				"Walk around the room.",
+			},
+		},
+		Notify: NotifyConfig{
+			WorkEnd:  true,
+			BreakEnd: true,
+			Bell:     true,
+		},
+		Tracking: TrackingConfig{
+			Overtime: true,
+		},
+		Keys: map[string][]string{
+			"up":     {"up", "k"},
+			"down":   {"down", "j"},
+			"start":  {"enter"},
+			"quit":   {"q", "ctrl+c"},
+			"pause":  {"space"},
+			"switch": {"h"},
+			"done":   {"d"},
+			"yes":    {"y"},
+			"no":     {"n"},
+			"skip":   {"s"},
+			"help":   {"?"},
+
+			"resume":  {"r"},
+			"commit":  {"c"},
+			"discard": {"x"},
+
+			"stopwatch": {"w"},
+			"search":    {"/"},
+			"filter":    {"f"},
+		},
+		Theme:   ThemeConfig{Name: "auto"},
+		Themes:  map[string]UserTheme{},
+		History: DefaultHistoryPath(),
+		Hooks:   map[string]string{},
+		Filters: map[string]string{
+			"open": "state:open",
+		},
+	}
+}
+
+// DefaultConfigPath returns cove/config.toml in XDG_CONFIG_HOME, falling
+// back to ~/.config
+func DefaultConfigPath() string {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "cove", "config.toml")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "cove", "config.toml")
+	}
+	return filepath.Join(".config", "cove", "config.toml")
+}
+
+// LoadConfig reads the config file at path and applies the named profile, or
+// the file's default profile when name is empty. A missing file gives the
+// defaults. Every problem found is reported, not just the first.
+func LoadConfig(path, profile string) (Config, error) {
+	file := configFile{Config: DefaultConfig()}
+
+	meta, err := toml.DecodeFile(path, &file)
+	if errors.Is(err, os.ErrNotExist) {
+		if profile != "" {
+			return file.Config, fmt.Errorf("unknown profile %q: %s does not exist", profile, path)
+		}
+		return file.Config, nil
+	}
+	if err != nil {
+		return file.Config, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if profile == "" {
+		profile = file.Profile
+	}
+	if _, ok := file.Profiles[profile]; profile != "" && !ok {
+		return file.Config, fmt.Errorf("%s: unknown profile %q%s", path, profile, profileList(file.Profiles))
+	}
+
+	// Every profile is checked so mistakes show up before switching to it
+	problems := file.Config.validate()
+	config := file.Config
+	for _, name := range sortedKeys(file.Profiles) {
+		merged := file.Config.clone()
+		if err := meta.PrimitiveDecode(file.Profiles[name], &merged); err != nil {
+			return file.Config, fmt.Errorf("%s: profile %q: %w", path, name, err)
+		}
+		for _, problem := range merged.validate() {
+			if !containsError(problems, problem) {
+				problems = append(problems, fmt.Errorf("profile %s: %w", name, problem))
+			}
+		}
+		if name == profile {
+			config = merged
+		}
+	}
+
+	for _, key := range meta.Undecoded() {
+		problems = append(problems, fmt.Errorf("unknown setting %q", key.String()))
+	}
+	if len(problems) > 0 {
+		return config, fmt.Errorf("%s: %w", path, errors.Join(problems...))
+	}
+
+	config.History = expandHome(config.History)
+	return config, nil
+}
+
+// clone copies c so that decoding a profile into the copy leaves c's maps
+// alone
+func (c Config) clone() Config {
+	c.Keys = maps.Clone(c.Keys)
+	c.Hooks = maps.Clone(c.Hooks)
+	c.Themes = maps.Clone(c.Themes)
+	c.Filters = maps.Clone(c.Filters)
+	return c
+}
+
+// validate returns every invalid setting
+func (c Config) validate() []error {
+	var problems []error
+
+	if c.Durations.Star.Duration < time.Minute {
+		problems = append(problems, errors.New("durations.star must be at least 1m"))
+	}
+	if c.Durations.Default.Duration <= 0 {
+		problems = append(problems, errors.New("durations.default must be positive"))
+	}
+	if c.Durations.Poll.Duration < 100*time.Millisecond {
+		problems = append(problems, errors.New("durations.poll must be at least 100ms"))
+	}
+
+	if c.Pomodoro.ShortBreak.Duration <= 0 {
+		problems = append(problems, errors.New("pomodoro.short_break must be positive"))
+	}
+	if c.Pomodoro.LongBreak.Duration <= 0 {
+		problems = append(problems, errors.New("pomodoro.long_break must be positive"))
+	}
+	if c.Pomodoro.LongBreakEvery < 1 {
+		problems = append(problems, errors.New("pomodoro.long_break_every must be at least 1"))
+	}
+	if !contains(breakModes, c.Pomodoro.BreakMode) {
+		problems = append(problems, fmt.Errorf("pomodoro.break_mode: unknown mode %q (have: %s)", c.Pomodoro.BreakMode, strings.Join(breakModes, ", ")))
+	}
+	if c.Pomodoro.SkipAfter.Duration < 0 {
+		problems = append(problems, errors.New("pomodoro.skip_after must not be negative"))
+	}
+
+	if autosave := c.Tracking.Autosave.Duration; autosave != 0 && autosave < time.Minute {
+		problems = append(problems, errors.New("tracking.autosave must be 0 (off) or at least 1m"))
+	}
+
+	if c.Goal.Pomodoros < 0 {
+		problems = append(problems, errors.New("goal.pomodoros must not be negative"))
+	}
+	if c.Goal.Focus.Duration < 0 {
+		problems = append(problems, errors.New("goal.focus must not be negative"))
+	}
+	if c.Goal.Pomodoros > 0 && c.Goal.Focus.Duration > 0 {
+		problems = append(problems, errors.New("goal takes either pomodoros or focus, not both"))
+	}
+
+	if !contains(oscModes, c.Notify.OSC) {
+		problems = append(problems, fmt.Errorf("notify.osc must be \"9\", \"777\" or empty, not %q", c.Notify.OSC))
+	}
+
+	for _, action := range sortedKeys(c.Keys) {
+		if !contains(keyActions, action) {
+			problems = append(problems, fmt.Errorf("keys.%s is not an action (have: %s)", action, strings.Join(keyActions, ", ")))
+		} else if len(c.Keys[action]) == 0 {
+			problems = append(problems, fmt.Errorf("keys.%s needs at least one key", action))
+		}
+	}
+
+	_, builtin := builtinThemes[c.Theme.Name]
+	if _, user := c.Themes[c.Theme.Name]; !builtin && !user {
+		problems = append(problems, fmt.Errorf("theme.name: unknown theme %q (have: %s)", c.Theme.Name, strings.Join(c.themeNames(), ", ")))
+	}
+	problems = append(problems, c.Theme.Palette.validate("theme")...)
+	for _, name := range sortedKeys(c.Themes) {
+		theme := c.Themes[name]
+		if _, ok := builtinThemes[name]; ok {
+			problems = append(problems, fmt.Errorf("themes.%s: built-in themes can't be redefined", name))
+		}
+		if _, ok := builtinThemes[theme.Base]; theme.Base != "" && !ok {
+			problems = append(problems, fmt.Errorf("themes.%s.base: %q is not a built-in theme", name, theme.Base))
+		}
+		problems = append(problems, theme.Palette.validate("themes."+name)...)
+	}
+
+	if strings.TrimSpace(c.History) == "" {
+		problems = append(problems, errors.New("history must not be empty"))
+	}
+
+	for _, name := range sortedKeys(c.Filters) {
+		if _, err := ParseFilter(c.Filters[name]); err != nil {
+			problems = append(problems, fmt.Errorf("filters.%s: %w", name, err))
+		}
+	}
+
+	for _, event := range sortedKeys(c.Hooks) {
+		if !contains(hookEvents, event) {
+			problems = append(problems, fmt.Errorf("hooks.%s is not an event (have: %s)", event, strings.Join(hookEvents, ", ")))
+		}
+	}
+
+	return problems
+}
+
+// themeNames lists the built-in and user themes
+func (c Config) themeNames() []string {
+	names := sortedKeys(builtinThemes)
+	for _, name := range sortedKeys(c.Themes) {
+		if _, ok := builtinThemes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validColor accepts hex colors and ANSI color numbers
+func validColor(color string) bool {
+	if colorRegex.MatchString(color) {
+		return true
+	}
+	n, err := strconv.Atoi(color)
+	return err == nil && n >= 0 && n <= 255
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
+
+func profileList(profiles map[string]toml.Primitive) string {
+	if len(profiles) == 0 {
+		return " (no profiles are defined)"
+	}
+	return " (have: " + strings.Join(sortedKeys(profiles), ", ") + ")"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsError reports whether an error with the same message is in errs
+func containsError(errs []error, err error) bool {
+	for _, e := range errs {
+		if e.Error() == err.Error() {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package cove
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		profile string
+		check   func(t *testing.T, config Config)
+		// errs are the messages the error has to contain
+		errs []string
+	}{
+		{
+			name: "empty file gives the defaults",
+			check: func(t *testing.T, config Config) {
+				if config.Estimates() != DefaultEstimates() {
+					t.Errorf("got estimates %+v", config.Estimates())
+				}
+				if config.Pomodoro.Enabled || config.Tracking.Autosave.Duration != 0 {
+					t.Error("pomodoro and autosave should be off by default")
+				}
+			},
+		},
+		{
+			name:    "durations",
+			content: "[durations]\nstar = \"10m\"\ndefault = \"30m\"\n",
+			check: func(t *testing.T, config Config) {
+				want := Estimates{Star: 10 * time.Minute, Default: 30 * time.Minute}
+				if config.Estimates() != want {
+					t.Errorf("got estimates %+v, want %+v", config.Estimates(), want)
+				}
+			},
+		},
+		{
+			name:    "profile from the file",
+			content: "profile = \"work\"\n[durations]\nstar = \"10m\"\n[profiles.work.durations]\nstar = \"15m\"\n",
+			check: func(t *testing.T, config Config) {
+				if config.Durations.Star.Duration != 15*time.Minute {
+					t.Errorf("got star %v, want the work profile's 15m", config.Durations.Star)
+				}
+			},
+		},
+		{
+			name:    "profile given overrides the file",
+			content: "profile = \"work\"\n[profiles.work.durations]\nstar = \"15m\"\n[profiles.home.durations]\nstar = \"2m\"\n",
+			profile: "home",
+			check: func(t *testing.T, config Config) {
+				if config.Durations.Star.Duration != 2*time.Minute {
+					t.Errorf("got star %v, want the home profile's 2m", config.Durations.Star)
+				}
+			},
+		},
+		{
+			name:    "unknown profile",
+			content: "[profiles.work.durations]\nstar = \"15m\"\n",
+			profile: "home",
+			errs:    []string{`unknown profile "home" (have: work)`},
+		},
+		{
+			name:    "bad duration",
+			content: "[durations]\nstar = \"soon\"\n",
+			errs:    []string{`invalid duration "soon"`},
+		},
+		{
+			name: "every problem is reported",
+			content: strings.Join([]string{
+				`[durations]`,
+				`star = "30s"`,
+				`[pomodoro]`,
+				`break_mode = "lenient"`,
+				`[tracking]`,
+				`autosave = "10s"`,
+				`[goal]`,
+				`pomodoros = 4`,
+				`focus = "2h"`,
+				`[keys]`,
+				`jump = ["j"]`,
+				`[theme]`,
+				`name = "neon"`,
+				`[themes.dark]`,
+				`accent = "red"`,
+				`[filters]`,
+				`mine = "owner:me"`,
+				`[hooks]`,
+				`crashed = "true"`,
+			}, "\n"),
+			errs: []string{
+				"durations.star must be at least 1m",
+				`pomodoro.break_mode: unknown mode "lenient"`,
+				"tracking.autosave must be 0 (off) or at least 1m",
+				"goal takes either pomodoros or focus, not both",
+				"keys.jump is not an action",
+				`theme.name: unknown theme "neon"`,
+				"themes.dark: built-in themes can't be redefined",
+				`themes.dark.accent: invalid color "red"`,
+				`filters.mine: unknown field "owner"`,
+				"hooks.crashed is not an event",
+			},
+		},
+		{
+			name:    "problems in profiles",
+			content: "[profiles.focus.pomodoro]\nlong_break_every = 0\n",
+			errs:    []string{"profile focus: pomodoro.long_break_every must be at least 1"},
+		},
+		{
+			name:    "unknown setting",
+			content: "[durations]\nlunch = \"1h\"\n",
+			errs:    []string{`unknown setting "durations.lunch"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "config.toml", tt.content)
+			config, err := LoadConfig(path, tt.profile)
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				tt.check(t, config)
+				return
+			}
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			for _, want := range tt.errs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+
+	config, err := LoadConfig(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Estimates() != DefaultEstimates() {
+		t.Errorf("got estimates %+v, want the defaults", config.Estimates())
+	}
+
+	if _, err := LoadConfig(path, "work"); err == nil {
+		t.Error("a profile was accepted without a config file")
+	}
+}
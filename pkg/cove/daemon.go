@@ -0,0 +1,216 @@
+package cove
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Request is one command sent to the daemon as a line of JSON
+type Request struct {
+	Command  string `json:"command"`
+	File     string `json:"file,omitempty"`
+	Todo     string `json:"todo,omitempty"`
+	MarkDone bool   `json:"done,omitempty"`
+}
+
+// Response answers a Request. For "subscribe" the response is followed by
+// one Event per line until the connection is closed.
+type Response struct {
+	OK      bool           `json:"ok"`
+	Error   string         `json:"error,omitempty"`
+	Code    string         `json:"code,omitempty"`
+	Active  *ActiveSession `json:"active,omitempty"`
+	Session *Session       `json:"session,omitempty"`
+	Todo    *Todo          `json:"todo,omitempty"`
+}
+
+// Error codes let clients turn a response back into the engine's sentinel errors
+var errorCodes = map[string]error{
+	"not_running":     ErrNotRunning,
+	"already_running": ErrAlreadyRunning,
+	"not_found":       ErrTodoNotFound,
+	"ambiguous":       ErrAmbiguousTodo,
+	"done":            ErrTodoDone,
+}
+
+// errorCode returns the code for one of the engine's errors, or ""
+func errorCode(err error) string {
+	for code, kind := range errorCodes {
+		if errors.Is(err, kind) {
+			return code
+		}
+	}
+	return ""
+}
+
+// DefaultSocketPath returns the daemon socket, in XDG_RUNTIME_DIR when set
+func DefaultSocketPath() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "cove.sock")
+	}
+	return filepath.Join(os.TempDir(), "cove-"+strconv.Itoa(os.Getuid())+".sock")
+}
+
+// Daemon serves an Engine over a Unix socket so the timer outlives any one
+// terminal
+type Daemon struct {
+	engine     *Engine
+	socketPath string
+}
+
+func NewDaemon(engine *Engine, socketPath string) *Daemon {
+	return &Daemon{
+		engine:     engine,
+		socketPath: socketPath,
+	}
+}
+
+// ListenAndServe accepts clients until ctx is cancelled
+func (d *Daemon) ListenAndServe(ctx context.Context) error {
+	// A socket left behind by a crashed daemon is removed; a live one is not
+	if conn, err := net.Dial("unix", d.socketPath); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already listening on %s", d.socketPath)
+	}
+	os.Remove(d.socketPath)
+
+	if err := os.MkdirAll(filepath.Dir(d.socketPath), 0o700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", d.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	defer os.Remove(d.socketPath)
+	if err := os.Chmod(d.socketPath, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict socket: %w", err)
+	}
+
+	go d.engine.Watch(ctx)
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.serve(ctx, conn)
+		}()
+	}
+}
+
+func (d *Daemon) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	// Don't let a client hang on to the connection past shutdown; done lets
+	// the watcher go once the client has
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	encoder := json.NewEncoder(conn)
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			encoder.Encode(Response{Error: "invalid request: " + err.Error()})
+			continue
+		}
+
+		if req.Command == "subscribe" {
+			d.stream(ctx, encoder)
+			return
+		}
+
+		if err := encoder.Encode(d.handle(req)); err != nil {
+			log.Printf("Daemon write error: %v", err)
+			return
+		}
+	}
+}
+
+func (d *Daemon) handle(req Request) Response {
+	var resp Response
+	var err error
+
+	switch req.Command {
+	case "status":
+		resp.Active, err = d.engine.Status()
+	case "start":
+		resp.Active, err = d.engine.Start(req.File, req.Todo)
+	case "switch":
+		resp.Active, err = d.engine.Switch(req.File, req.Todo)
+	case "pause":
+		resp.Active, err = d.engine.Pause()
+	case "resume":
+		resp.Active, err = d.engine.Resume()
+	case "stop":
+		var session Session
+		if session, err = d.engine.Stop(req.MarkDone); err == nil {
+			resp.Session = &session
+		}
+	case "discard":
+		resp.Active, err = d.engine.Discard()
+	case "done":
+		var todo Todo
+		if todo, err = d.engine.Done(req.File, req.Todo); err == nil {
+			resp.Todo = &todo
+		}
+	default:
+		err = fmt.Errorf("unknown command %q", req.Command)
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+		resp.Code = errorCode(err)
+		return resp
+	}
+	resp.OK = true
+	return resp
+}
+
+// stream forwards engine events to a subscribed client until it goes away
+func (d *Daemon) stream(ctx context.Context, encoder *json.Encoder) {
+	events, cancel, _ := d.engine.Subscribe()
+	defer cancel()
+
+	if err := encoder.Encode(Response{OK: true}); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package cove
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/timer"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startDaemon serves a test engine on a socket in a temporary directory
+// until the test ends
+func startDaemon(t *testing.T) (*Engine, *Client, string) {
+	t.Helper()
+	engine, path := newTestEngine(t)
+	socketPath := filepath.Join(t.TempDir(), "cove.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- NewDaemon(engine, socketPath).ListenAndServe(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("daemon: %v", err)
+		}
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		client, err := DialDaemon(socketPath)
+		if err == nil {
+			return engine, client, path
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// nextEvent waits for an event from the daemon
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event from the daemon")
+	}
+	return Event{}
+}
+
+func TestDaemonClient(t *testing.T) {
+	_, client, path := startDaemon(t)
+	events, cancel, err := client.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	if active, err := client.Status(); err != nil || active != nil {
+		t.Fatalf("got %+v, %v before starting", active, err)
+	}
+
+	steps := []struct {
+		name  string
+		call  func() error
+		err   error
+		event string
+	}{
+		{name: "start", call: func() error { _, err := client.Start(path, "report"); return err }, event: EventStarted},
+		{name: "start again", call: func() error { _, err := client.Start(path, "docs"); return err }, err: ErrAlreadyRunning},
+		{name: "pause", call: func() error { _, err := client.Pause(); return err }, event: EventPaused},
+		{name: "resume", call: func() error { _, err := client.Resume(); return err }, event: EventResumed},
+		{name: "switch", call: func() error { _, err := client.Switch(path, "docs"); return err }, event: EventStopped},
+		{name: "ambiguous", call: func() error { _, err := client.Done(path, "review"); return err }, err: ErrAmbiguousTodo},
+		{name: "done", call: func() error { _, err := client.Done(path, "docs"); return err }, event: EventDone},
+		{name: "stop", call: func() error { _, err := client.Stop(false); return err }, err: ErrNotRunning},
+		{name: "start done", call: func() error { _, err := client.Start(path, "deploy"); return err }, err: ErrTodoDone},
+		{name: "start missing", call: func() error { _, err := client.Start(path, "lunch"); return err }, err: ErrTodoNotFound},
+		{name: "start another", call: func() error { _, err := client.Start(path, "PR"); return err }, event: EventStarted},
+		{name: "discard", call: func() error { _, err := client.Discard(); return err }, event: EventDiscarded},
+	}
+
+	for _, step := range steps {
+		err := step.call()
+		if !errors.Is(err, step.err) {
+			t.Fatalf("%s: got error %v, want %v", step.name, err, step.err)
+		}
+		if step.event != "" {
+			if event := nextEvent(t, events); event.Type != step.event {
+				t.Fatalf("%s: got event %q, want %q", step.name, event.Type, step.event)
+			}
+		}
+		if step.name == "switch" {
+			// The switch goes on to start the new todo
+			nextEvent(t, events)
+			nextEvent(t, events)
+		}
+	}
+
+	if active, err := client.Status(); err != nil || active != nil {
+		t.Errorf("got %+v, %v after discarding", active, err)
+	}
+	if content := readFile(t, path); content != "# Today\n- [ ] Write report **\n- [ ] Review PR *\n- [x] Deploy\n- [x] Review docs\n" {
+		t.Errorf("got file:\n%s", content)
+	}
+}
+
+func TestDaemonReleasesConnections(t *testing.T) {
+	_, client, _ := startDaemon(t)
+	if _, err := client.Status(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	before := runtime.NumGoroutine()
+
+	for range 50 {
+		if _, err := client.Status(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The daemon notices closed connections on its own time
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before+5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines before 50 calls, %d after", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// isolate keeps the TUI's state and history files in a temporary directory
+func isolate(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+}
+
+// followDaemon hands the timer the next event from the daemon
+func followDaemon(t *testing.T, m tea.Model) tea.Model {
+	t.Helper()
+	m, _ = m.Update(daemonEventMsg{event: nextEvent(t, m.(TimerModel).events)})
+	return m
+}
+
+func TestTimerFollowsDaemon(t *testing.T) {
+	isolate(t)
+	engine, client, path := startDaemon(t)
+	todos, err := ReadTodos(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selector := NewTodoSelector(todos, path).WithDaemon(client)
+
+	var m tea.Model = NewBubblesTimer(&selector.todos[0], selector, 0)
+	if m.(TimerModel).active == nil {
+		t.Fatal("the timer isn't following the daemon")
+	}
+
+	// Marking another todo done leaves the session alone
+	if _, err := engine.Done(path, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if m = followDaemon(t, m); !isTimer(m) {
+		t.Fatal("the timer left when another todo was done")
+	}
+
+	// Extending the session has the daemon time another interval
+	timedOut := m.(TimerModel)
+	started := timedOut.active.Started
+	timedOut.timer.Timeout = 0
+	m, _ = timedOut.Update(timer.TimeoutMsg{ID: timedOut.timer.ID()})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if err := m.(TimerModel).err; err != nil {
+		t.Fatal(err)
+	}
+	if m.(TimerModel).active.Started.Equal(started) {
+		t.Fatal("extending didn't start another interval")
+	}
+	// The stopped, started and switched events of the extension
+	for range 3 {
+		if m = followDaemon(t, m); !isTimer(m) {
+			t.Fatal("the timer left on its own extension")
+		}
+	}
+
+	// Another client stopping the session ends the timer
+	if _, err := engine.Stop(false); err != nil {
+		t.Fatal(err)
+	}
+	if m = followDaemon(t, m); isTimer(m) {
+		t.Fatal("the timer stayed after the session was stopped")
+	}
+}
+
+func TestTimerShowsDaemonErrors(t *testing.T) {
+	isolate(t)
+	_, client, path := startDaemon(t)
+	todos, err := ReadTodos(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selector := NewTodoSelector(todos, path).WithDaemon(client)
+	selector.pomodoro.Enabled = true
+
+	tests := []struct {
+		name string
+		msg  func(TimerModel) tea.Msg
+	}{
+		{name: "switch", msg: func(TimerModel) tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")} }},
+		{name: "done", msg: func(TimerModel) tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")} }},
+		{name: "end of work", msg: func(m TimerModel) tea.Msg { return timer.TimeoutMsg{ID: m.timer.ID()} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(engineTodos), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			m := NewBubblesTimer(&selector.todos[0], selector, 0)
+			t.Cleanup(func() { client.Discard() })
+			// The todo disappears, so recording it fails
+			if err := os.WriteFile(path, []byte("# Nothing left\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, _ := m.Update(tt.msg(m))
+			model, ok := got.(TimerModel)
+			if !ok || model.err == nil || model.phase != phaseWork {
+				t.Fatalf("got %T without an error on the timer", got)
+			}
+		})
+	}
+}
+
+func isTimer(m tea.Model) bool {
+	_, ok := m.(TimerModel)
+	return ok
+}
+
+func TestTimerDaemonTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		overtime bool
+		paused   bool
+		view     string
+	}{
+		{name: "overtime", overtime: true, view: "over the 10 minute estimate"},
+		{name: "no overtime", paused: true, view: "Add another 10 minutes?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			_, client, path := startDaemon(t)
+			todos, err := ReadTodos(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			selector := NewTodoSelector(todos, path).WithDaemon(client)
+			selector.tracking.Overtime = tt.overtime
+
+			var m tea.Model = NewBubblesTimer(&selector.todos[0], selector, 0)
+			m = timeOut(m)
+			if err := m.(TimerModel).err; err != nil {
+				t.Fatal(err)
+			}
+			active, err := client.Status()
+			if err != nil || active == nil || active.Paused != tt.paused {
+				t.Fatalf("got daemon session %+v, %v, want paused %v", active, err, tt.paused)
+			}
+			if view := m.View(); !strings.Contains(view, tt.view) {
+				t.Errorf("view does not have %q:\n%s", tt.view, view)
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package cove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotRunning     = errors.New("no timer is running")
+	ErrAlreadyRunning = errors.New("a timer is already running")
+	ErrTodoNotFound   = errors.New("todo not found")
+	ErrAmbiguousTodo  = errors.New("pattern matches several todos")
+	ErrTodoDone       = errors.New("todo is already done")
+)
+
+// engineError keeps a detailed message while still matching one of the
+// sentinel errors above with errors.Is
+type engineError struct {
+	kind error
+	msg  string
+}
+
+func (e *engineError) Error() string { return e.msg }
+func (e *engineError) Unwrap() error { return e.kind }
+
+func newEngineError(kind error, format string, args ...any) error {
+	return &engineError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// Event types sent to subscribers
+const (
+	EventStarted   = "started"
+	EventPaused    = "paused"
+	EventResumed   = "resumed"
+	EventStopped   = "stopped"
+	EventDone      = "done"
+	EventDiscarded = "discarded"
+	EventTimeout   = "timeout"
+	// EventSwitched follows the stopped and started events of a switch,
+	// with the new session as Active and the recorded one as Session
+	EventSwitched = "switched"
+	// EventReloaded is sent when the TUI re-reads a changed markdown File
+	EventReloaded = "reloaded"
+)
+
+// Event describes a change to the running session. Active is the session
+// after the change and Session is what was recorded when it ended.
+type Event struct {
+	Type    string         `json:"type"`
+	Time    time.Time      `json:"time"`
+	Active  *ActiveSession `json:"active,omitempty"`
+	Session *Session       `json:"session,omitempty"`
+	File    string         `json:"file,omitempty"`
+	// Todo and Line describe the todo of a done event that had no session
+	Todo string `json:"todo,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// Controller drives the running session. Engine implements it in-process and
+// Client implements it by talking to a `cove daemon`. Todos are selected by
+// file and a line number or pattern, as accepted by FindTodo.
+type Controller interface {
+	Status() (*ActiveSession, error)
+	Start(filename, todo string) (*ActiveSession, error)
+	Switch(filename, todo string) (*ActiveSession, error)
+	Pause() (*ActiveSession, error)
+	Resume() (*ActiveSession, error)
+	Stop(markDone bool) (Session, error)
+	Discard() (*ActiveSession, error)
+	Done(filename, todo string) (Todo, error)
+	Subscribe() (<-chan Event, func(), error)
+}
+
+// Engine owns the active session and every file write that comes from it.
+// The session lives in the state file, so separate processes using the same
+// paths see the same timer.
+type Engine struct {
+	statePath   string
+	historyPath string
+	hooks       *Hooks
+	bus         *EventBus
+	autosave    time.Duration
+	estimates   Estimates
+
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewEngine(statePath, historyPath string) *Engine {
+	return &Engine{
+		statePath:   statePath,
+		historyPath: historyPath,
+		estimates:   DefaultEstimates(),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// WithHooks runs the user's hook commands for every event the engine emits
+func (e *Engine) WithHooks(hooks *Hooks) *Engine {
+	e.hooks = hooks
+	return e
+}
+
+// WithAutosave makes Watch add the time worked to the todo in the markdown
+// file after every interval of work, so long sessions show up before they
+// end. Zero turns it off.
+func (e *Engine) WithAutosave(interval time.Duration) *Engine {
+	e.autosave = interval
+	return e
+}
+
+// WithEstimates reads the star hints of todos with the given estimates
+func (e *Engine) WithEstimates(estimates Estimates) *Engine {
+	e.estimates = estimates
+	return e
+}
+
+// WithBus publishes the engine's events on bus as typed events. Subscribers
+// are called while the engine is locked, so they must not call it.
+func (e *Engine) WithBus(bus *EventBus) *Engine {
+	e.bus = bus
+	return e
+}
+
+func (e *Engine) Status() (*ActiveSession, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return LoadActiveSession(e.statePath)
+}
+
+func (e *Engine) Start(filename, todo string) (*ActiveSession, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active, err := LoadActiveSession(e.statePath)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, newEngineError(ErrAlreadyRunning, "already working on %q", active.Description)
+	}
+	return e.start(filename, todo)
+}
+
+// Switch records the running session, if any, and starts another todo
+func (e *Engine) Switch(filename, todo string) (*ActiveSession, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active, err := LoadActiveSession(e.statePath)
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return e.start(filename, todo)
+	}
+
+	session, err := e.stop(active, false)
+	if err != nil {
+		return nil, err
+	}
+	if active, err = e.start(filename, todo); err != nil {
+		return nil, err
+	}
+	e.emit(Event{Type: EventSwitched, Active: active, Session: &session})
+	return active, nil
+}
+
+func (e *Engine) Pause() (*ActiveSession, error) {
+	return e.update(EventPaused, (*ActiveSession).Pause)
+}
+
+func (e *Engine) Resume() (*ActiveSession, error) {
+	return e.update(EventResumed, (*ActiveSession).Resume)
+}
+
+// Stop ends the running session, adding its time to the todo in the
+// markdown file and to the history
+func (e *Engine) Stop(markDone bool) (Session, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active, err := LoadActiveSession(e.statePath)
+	if err != nil {
+		return Session{}, err
+	}
+	if active == nil {
+		return Session{}, ErrNotRunning
+	}
+	return e.stop(active, markDone)
+}
+
+// Discard drops the running session without recording any time
+func (e *Engine) Discard() (*ActiveSession, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active, err := LoadActiveSession(e.statePath)
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return nil, ErrNotRunning
+	}
+	if err := ClearActiveSession(e.statePath); err != nil {
+		return nil, err
+	}
+	e.emit(Event{Type: EventDiscarded, Active: active})
+	return active, nil
+}
+
+// Done marks a todo as done. If it is the one being timed, the session is
+// stopped and recorded as well.
+func (e *Engine) Done(filename, todo string) (Todo, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	todos, err := ReadTodosWithEstimates(filename, e.estimates)
+	if err != nil {
+		return Todo{}, err
+	}
+	index, err := FindTodo(todos, todo)
+	if err != nil {
+		return Todo{}, err
+	}
+
+	active, err := LoadActiveSession(e.statePath)
+	if err != nil {
+		return Todo{}, err
+	}
+	if active != nil && absPath(active.File) == absPath(filename) &&
+		MatchTodo(todos, active.Line, active.Description) == index {
+		session, err := e.stop(active, true)
+		if err != nil {
+			return Todo{}, err
+		}
+		if todos, err = ReadTodosWithEstimates(filename, e.estimates); err != nil {
+			return Todo{}, err
+		}
+		return todos[MatchTodo(todos, session.Line, session.Description)], nil
+	}
+
+	todos[index].MarkDone()
+	if err := WriteTodos(filename, todos); err != nil {
+		return Todo{}, err
+	}
+	e.bus.Publish(TodoChanged{Time: time.Now(), File: absPath(filename), Todo: todos[index]})
+	e.emit(Event{Type: EventDone, File: absPath(filename), Todo: todos[index].Description, Line: todos[index].LineNumber})
+	return todos[index], nil
+}
+
+// Subscribe returns a channel of events and a function to stop receiving
+// them. Slow subscribers miss events rather than blocking the engine.
+func (e *Engine) Subscribe() (<-chan Event, func(), error) {
+	ch := make(chan Event, 16)
+
+	e.mu.Lock()
+	e.subscribers[ch] = struct{}{}
+	e.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			e.mu.Lock()
+			delete(e.subscribers, ch)
+			e.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel, nil
+}
+
+// Watch emits a timeout event once per session when its estimate runs out,
+// and autosaves the running session if that is turned on. It returns when
+// ctx is cancelled.
+func (e *Engine) Watch(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var notified time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		e.mu.Lock()
+		active, err := LoadActiveSession(e.statePath)
+		if err == nil && active != nil && !active.Paused && active.Estimate > 0 &&
+			active.Remaining() == 0 && !active.Started.Equal(notified) {
+			notified = active.Started
+			e.emit(Event{Type: EventTimeout, Active: active})
+		}
+		if err == nil && active != nil && e.autosave > 0 &&
+			active.Elapsed()-active.Saved >= e.autosave {
+			if err := e.save(active); err != nil {
+				// Tried again on the next tick
+			}
+		}
+		e.mu.Unlock()
+	}
+}
+
+// start begins timing a todo; the caller holds e.mu and has made sure no
+// other session is running
+func (e *Engine) start(filename, selector string) (*ActiveSession, error) {
+	todos, err := ReadTodosWithEstimates(filename, e.estimates)
+	if err != nil {
+		return nil, err
+	}
+	index, err := FindTodo(todos, selector)
+	if err != nil {
+		return nil, err
+	}
+	todo := todos[index]
+	if todo.State == Done {
+		return nil, newEngineError(ErrTodoDone, "%q is already done", todo.Description)
+	}
+
+	now := time.Now()
+	active := &ActiveSession{
+		File:        absPath(filename),
+		Description: todo.Description,
+		Line:        todo.LineNumber,
+		Estimate:    todo.EstimatedTime,
+		Started:     now,
+		Resumed:     now,
+	}
+	if err := SaveActiveSession(e.statePath, active); err != nil {
+		return nil, err
+	}
+	e.emit(Event{Type: EventStarted, Active: active})
+	return active, nil
+}
+
+// stop records active into the markdown file and history and clears the
+// state file; the caller holds e.mu
+func (e *Engine) stop(active *ActiveSession, markDone bool) (Session, error) {
+	todos, err := ReadTodosWithEstimates(active.File, e.estimates)
+	if err != nil {
+		return Session{}, err
+	}
+	index := MatchTodo(todos, active.Line, active.Description)
+	if index < 0 {
+		return Session{}, newEngineError(ErrTodoNotFound, "%q is no longer in %s", active.Description, active.File)
+	}
+
+	elapsed := active.Elapsed()
+	todos[index].AddTime(elapsed - active.Saved)
+	if markDone {
+		todos[index].MarkDone()
+	}
+	if err := WriteTodos(active.File, todos); err != nil {
+		return Session{}, err
+	}
+	e.bus.Publish(TodoChanged{Time: time.Now(), File: active.File, Todo: todos[index]})
+
+	session := Session{
+		Description: todos[index].Description,
+		File:        active.File,
+		Line:        todos[index].LineNumber,
+		Start:       active.Started,
+		End:         time.Now(),
+		Duration:    elapsed,
+		Estimate:    active.Estimate,
+		Overtime:    overtime(elapsed, active.Estimate),
+		Completed:   markDone,
+	}
+	if err := AppendSession(e.historyPath, session); err != nil {
+		return session, err
+	}
+	if err := ClearActiveSession(e.statePath); err != nil {
+		return session, err
+	}
+
+	eventType := EventStopped
+	if markDone {
+		eventType = EventDone
+	}
+	e.emit(Event{Type: eventType, Session: &session})
+	return session, nil
+}
+
+// save adds the time worked since the last save to the todo without ending
+// the session. The file only holds whole minutes, so the rest is left for
+// the next save. The caller holds e.mu.
+func (e *Engine) save(active *ActiveSession) error {
+	todos, err := ReadTodosWithEstimates(active.File, e.estimates)
+	if err != nil {
+		return err
+	}
+	index := MatchTodo(todos, active.Line, active.Description)
+	if index < 0 {
+		return newEngineError(ErrTodoNotFound, "%q is no longer in %s", active.Description, active.File)
+	}
+
+	unsaved := (active.Elapsed() - active.Saved).Truncate(time.Minute)
+	todos[index].AddTime(unsaved)
+	if err := WriteTodos(active.File, todos); err != nil {
+		return err
+	}
+	active.Saved += unsaved
+	if err := SaveActiveSession(e.statePath, active); err != nil {
+		return err
+	}
+	e.bus.Publish(TodoChanged{Time: time.Now(), File: active.File, Todo: todos[index]})
+	return nil
+}
+
+func (e *Engine) update(eventType string, change func(*ActiveSession)) (*ActiveSession, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active, err := LoadActiveSession(e.statePath)
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return nil, ErrNotRunning
+	}
+
+	change(active)
+	if err := SaveActiveSession(e.statePath, active); err != nil {
+		return nil, err
+	}
+	e.emit(Event{Type: eventType, Active: active})
+	return active, nil
+}
+
+// emit sends an event to every subscriber and the bus and runs its hook;
+// the caller holds e.mu
+func (e *Engine) emit(event Event) {
+	event.Time = time.Now()
+	e.hooks.Run(event)
+	e.bus.publishSession(event)
+	for ch := range e.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package cove
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const engineTodos = `# Today
+- [ ] Write report **
+- [ ] Review PR *
+- [x] Deploy
+- [ ] Review docs
+`
+
+// writeFile writes content to name in a temporary directory and returns
+// its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// newTestEngine returns an engine keeping its state and history in a
+// temporary directory, and a markdown file of engineTodos
+func newTestEngine(t *testing.T) (*Engine, string) {
+	t.Helper()
+	dir := t.TempDir()
+	engine := NewEngine(filepath.Join(dir, "state.json"), filepath.Join(dir, "history.jsonl"))
+	return engine, writeFile(t, "todos.md", engineTodos)
+}
+
+// backdate makes the running session look like it started d earlier
+func backdate(t *testing.T, e *Engine, d time.Duration) *ActiveSession {
+	t.Helper()
+	active, err := LoadActiveSession(e.statePath)
+	if err != nil || active == nil {
+		t.Fatalf("no running session: %v", err)
+	}
+	active.Started = active.Started.Add(-d)
+	active.Resumed = active.Resumed.Add(-d)
+	if err := SaveActiveSession(e.statePath, active); err != nil {
+		t.Fatal(err)
+	}
+	return active
+}
+
+func readHistory(t *testing.T, e *Engine) []Session {
+	t.Helper()
+	sessions, err := ReadSessions(e.historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sessions
+}
+
+func TestEngineStart(t *testing.T) {
+	tests := []struct {
+		selector    string
+		description string
+		estimate    time.Duration
+		err         error
+	}{
+		{selector: "2", description: "Write report", estimate: 10 * time.Minute},
+		{selector: "report", description: "Write report", estimate: 10 * time.Minute},
+		{selector: "docs", description: "Review docs", estimate: 20 * time.Minute},
+		{selector: "deploy", err: ErrTodoDone},
+		{selector: "review", err: ErrAmbiguousTodo},
+		{selector: "lunch", err: ErrTodoNotFound},
+		{selector: "1", err: ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			engine, path := newTestEngine(t)
+			active, err := engine.Start(path, tt.selector)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if active.Description != tt.description || active.Estimate != tt.estimate || active.File != absPath(path) {
+				t.Errorf("got %+v", active)
+			}
+			if _, err := engine.Start(path, tt.selector); !errors.Is(err, ErrAlreadyRunning) {
+				t.Errorf("starting twice gave %v, want %v", err, ErrAlreadyRunning)
+			}
+		})
+	}
+}
+
+func TestEngineStop(t *testing.T) {
+	tests := []struct {
+		name     string
+		markDone bool
+		want     string
+	}{
+		{name: "stop", want: "- [ ] Write report ** (took 30m)"},
+		{name: "done", markDone: true, want: "- [x] Write report ** (took 30m)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, path := newTestEngine(t)
+			if _, err := engine.Start(path, "report"); err != nil {
+				t.Fatal(err)
+			}
+			backdate(t, engine, 30*time.Minute)
+
+			session, err := engine.Stop(tt.markDone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if session.Duration.Truncate(time.Minute) != 30*time.Minute || session.Overtime.Truncate(time.Minute) != 20*time.Minute ||
+				session.Completed != tt.markDone || session.Line != 2 {
+				t.Errorf("got session %+v", session)
+			}
+			if content := readFile(t, path); !strings.Contains(content, tt.want+"\n") {
+				t.Errorf("file does not have %q:\n%s", tt.want, content)
+			}
+			if history := readHistory(t, engine); len(history) != 1 || history[0].Description != "Write report" {
+				t.Errorf("got history %+v", history)
+			}
+			if active, _ := engine.Status(); active != nil {
+				t.Errorf("still running %+v", active)
+			}
+			if _, err := engine.Stop(false); !errors.Is(err, ErrNotRunning) {
+				t.Errorf("stopping twice gave %v, want %v", err, ErrNotRunning)
+			}
+		})
+	}
+}
+
+func TestEnginePause(t *testing.T) {
+	engine, path := newTestEngine(t)
+	if _, err := engine.Start(path, "report"); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, engine, 10*time.Minute)
+
+	active, err := engine.Pause()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !active.Paused || active.Elapsed().Truncate(time.Minute) != 10*time.Minute {
+		t.Fatalf("got %+v", active)
+	}
+	// Time spent paused doesn't count
+	backdate(t, engine, time.Hour)
+	if active, err = engine.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if active.Paused || active.Elapsed().Truncate(time.Minute) != 10*time.Minute {
+		t.Fatalf("got %+v", active)
+	}
+
+	if _, err := engine.Stop(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Pause(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("pausing without a session gave %v, want %v", err, ErrNotRunning)
+	}
+}
+
+func TestEngineSwitch(t *testing.T) {
+	engine, path := newTestEngine(t)
+	events, cancel, err := engine.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	if _, err := engine.Switch(path, "report"); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, engine, 15*time.Minute)
+	active, err := engine.Switch(path, "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active.Description != "Review docs" {
+		t.Errorf("switched to %+v", active)
+	}
+
+	if content := readFile(t, path); !strings.Contains(content, "- [ ] Write report ** (took 15m)\n") {
+		t.Errorf("switching didn't record the time:\n%s", content)
+	}
+	if history := readHistory(t, engine); len(history) != 1 {
+		t.Errorf("got history %+v", history)
+	}
+
+	var types []string
+	for len(events) > 0 {
+		types = append(types, (<-events).Type)
+	}
+	want := []string{EventStarted, EventStopped, EventStarted, EventSwitched}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("got events %v, want %v", types, want)
+	}
+}
+
+func TestEngineDone(t *testing.T) {
+	engine, path := newTestEngine(t)
+	if _, err := engine.Start(path, "report"); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, engine, 5*time.Minute)
+
+	// Another todo is marked done while the session keeps running
+	if _, err := engine.Done(path, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := engine.Status(); active == nil {
+		t.Fatal("marking another todo done stopped the session")
+	}
+
+	// The running todo is recorded as well
+	todo, err := engine.Done(path, "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.State != Done || todo.TimeSpent != 5*time.Minute {
+		t.Errorf("got %+v", todo)
+	}
+	if active, _ := engine.Status(); active != nil {
+		t.Errorf("still running %+v", active)
+	}
+	content := readFile(t, path)
+	for _, want := range []string{"- [x] Write report ** (took 5m)\n", "- [x] Review docs\n"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("file does not have %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestEngineDiscard(t *testing.T) {
+	engine, path := newTestEngine(t)
+	if _, err := engine.Start(path, "report"); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, engine, 30*time.Minute)
+
+	if _, err := engine.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if content := readFile(t, path); content != engineTodos {
+		t.Errorf("discarding changed the file:\n%s", content)
+	}
+	if history := readHistory(t, engine); len(history) != 0 {
+		t.Errorf("got history %+v", history)
+	}
+}
+
+func TestEngineAutosave(t *testing.T) {
+	engine, path := newTestEngine(t)
+	engine.WithAutosave(5 * time.Minute)
+	if _, err := engine.Start(path, "report"); err != nil {
+		t.Fatal(err)
+	}
+	active := backdate(t, engine, 12*time.Minute+30*time.Second)
+
+	if err := engine.save(active); err != nil {
+		t.Fatal(err)
+	}
+	if active.Saved != 12*time.Minute {
+		t.Errorf("saved %v, want the whole minutes", active.Saved)
+	}
+	if content := readFile(t, path); !strings.Contains(content, "- [ ] Write report ** (took 12m)\n") {
+		t.Errorf("autosave didn't write the time:\n%s", content)
+	}
+
+	// Saving works on the file as it is now, keeping edits made meanwhile
+	edited := "- [ ] Added meanwhile\n" + readFile(t, path)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, engine, 5*time.Minute)
+
+	session, err := engine.Stop(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Duration.Truncate(time.Minute) != 17*time.Minute {
+		t.Errorf("got session %+v", session)
+	}
+	content := readFile(t, path)
+	for _, want := range []string{"- [ ] Added meanwhile\n", "- [ ] Write report ** (took 17m)\n"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("file does not have %q:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package cove
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EstimateSample is a finished todo, comparing the time it took with what
+// was estimated
+type EstimateSample struct {
+	Todo Todo
+	File string
+	// Completed is when the todo was marked done, taken from the session
+	// history; it is zero when no completing session was recorded
+	Completed time.Time
+}
+
+// Ratio is the time spent over the estimate: 1.5 took half as long again
+func (s EstimateSample) Ratio() float64 {
+	return float64(s.Todo.TimeSpent) / float64(s.Todo.EstimatedTime)
+}
+
+// EstimateSamples collects the done todos of a file that were given an
+// estimate with stars and have time spent, dated by the session that
+// finished them. Todos without stars only have the default estimate, which
+// nobody chose, so they would skew the report.
+func EstimateSamples(filename string, todos []Todo, sessions []Session) []EstimateSample {
+	sessions = SessionsForFile(sessions, filename)
+
+	var samples []EstimateSample
+	for _, todo := range todos {
+		if todo.State != Done || todo.Stars == 0 || !todo.HasEstimate() || todo.TimeSpent <= 0 {
+			continue
+		}
+		sample := EstimateSample{Todo: todo, File: absPath(filename), Completed: completedAt(todo, sessions)}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// Accuracy sums up how a group of todos went against their estimates
+type Accuracy struct {
+	Key       string
+	Todos     int
+	Estimated time.Duration
+	Spent     time.Duration
+	// Over counts the todos that took longer than estimated
+	Over int
+}
+
+// Ratio is the total time spent over the total estimate
+func (a Accuracy) Ratio() float64 {
+	if a.Estimated <= 0 {
+		return 0
+	}
+	return float64(a.Spent) / float64(a.Estimated)
+}
+
+func (a *Accuracy) add(sample EstimateSample) {
+	a.Todos++
+	a.Estimated += sample.Todo.EstimatedTime
+	a.Spent += sample.Todo.TimeSpent
+	if sample.Todo.TimeSpent > sample.Todo.EstimatedTime {
+		a.Over++
+	}
+}
+
+// AccuracyReport breaks estimate accuracy down by person, tag, section and
+// period. Todos with several people or tags count towards each of them.
+type AccuracyReport struct {
+	Overall  Accuracy
+	People   []Accuracy
+	Tags     []Accuracy
+	Sections []Accuracy
+	Periods  []Accuracy
+}
+
+// AccuracyRecord is the machine-readable form of an Accuracy
+type AccuracyRecord struct {
+	Key              string  `json:"key"`
+	Todos            int     `json:"todos"`
+	EstimateSeconds  int64   `json:"estimate_seconds"`
+	TimeSpentSeconds int64   `json:"time_spent_seconds"`
+	Over             int     `json:"over"`
+	Ratio            float64 `json:"ratio"`
+}
+
+// AccuracyReportRecord is the machine-readable form of an AccuracyReport
+type AccuracyReportRecord struct {
+	Overall  AccuracyRecord   `json:"overall"`
+	People   []AccuracyRecord `json:"people"`
+	Tags     []AccuracyRecord `json:"tags"`
+	Sections []AccuracyRecord `json:"sections"`
+	Periods  []AccuracyRecord `json:"periods"`
+}
+
+func NewAccuracyRecord(a Accuracy) AccuracyRecord {
+	return AccuracyRecord{
+		Key:              a.Key,
+		Todos:            a.Todos,
+		EstimateSeconds:  int64(a.Estimated.Seconds()),
+		TimeSpentSeconds: int64(a.Spent.Seconds()),
+		Over:             a.Over,
+		Ratio:            a.Ratio(),
+	}
+}
+
+func NewAccuracyReportRecord(report AccuracyReport) AccuracyReportRecord {
+	records := func(groups []Accuracy) []AccuracyRecord {
+		result := make([]AccuracyRecord, 0, len(groups))
+		for _, a := range groups {
+			result = append(result, NewAccuracyRecord(a))
+		}
+		return result
+	}
+	return AccuracyReportRecord{
+		Overall:  NewAccuracyRecord(report.Overall),
+		People:   records(report.People),
+		Tags:     records(report.Tags),
+		Sections: records(report.Sections),
+		Periods:  records(report.Periods),
+	}
+}
+
+// Periods that samples can be grouped into over time
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// NewAccuracyReport groups samples, putting completion dates into weeks or
+// months. Samples with no completion date are left out of the periods.
+func NewAccuracyReport(samples []EstimateSample, period string) AccuracyReport {
+	report := AccuracyReport{Overall: Accuracy{Key: "all"}}
+	people := map[string]*Accuracy{}
+	tags := map[string]*Accuracy{}
+	sections := map[string]*Accuracy{}
+	periods := map[string]*Accuracy{}
+
+	for _, sample := range samples {
+		report.Overall.add(sample)
+		for _, person := range sample.Todo.People {
+			group(people, person).add(sample)
+		}
+		for _, tag := range sample.Todo.Tags {
+			group(tags, tag).add(sample)
+		}
+		if sample.Todo.Section != "" {
+			group(sections, sample.Todo.Section).add(sample)
+		}
+		if !sample.Completed.IsZero() {
+			group(periods, periodKey(sample.Completed, period)).add(sample)
+		}
+	}
+
+	report.People = sortedGroups(people)
+	report.Tags = sortedGroups(tags)
+	report.Sections = sortedGroups(sections)
+	report.Periods = sortedGroups(periods)
+	return report
+}
+
+func group(groups map[string]*Accuracy, key string) *Accuracy {
+	if groups[key] == nil {
+		groups[key] = &Accuracy{Key: key}
+	}
+	return groups[key]
+}
+
+func sortedGroups(groups map[string]*Accuracy) []Accuracy {
+	result := make([]Accuracy, 0, len(groups))
+	for _, a := range groups {
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// periodKey names the week or month t falls in, so that keys sort in time
+// order: "2024-W07" or "2024-02"
+func periodKey(t time.Time, period string) string {
+	t = t.Local()
+	if period == PeriodMonth {
+		return t.Format("2006-01")
+	}
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// Suggestion is an estimate for a new todo, based on similar finished ones
+type Suggestion struct {
+	Estimate time.Duration
+	// Similar are the finished todos it is based on, most similar first
+	Similar []EstimateSample
+}
+
+// maxSimilar is how many of the most similar finished todos a suggestion
+// is based on
+const maxSimilar = 5
+
+var wordRegex = regexp.MustCompile(`[#@]?[\p{L}\p{N}_-]+`)
+
+// SuggestEstimate suggests an estimate for a todo described like
+// description, from the time the most similar finished todos took. Todos
+// are similar when they share words, #tags or @people. The suggestion is
+// rounded up to whole stars. It returns false when nothing is similar.
+func SuggestEstimate(samples []EstimateSample, description string, estimates Estimates) (Suggestion, bool) {
+	words := todoWords(description)
+
+	type scored struct {
+		sample EstimateSample
+		score  float64
+	}
+	var matches []scored
+	for _, sample := range samples {
+		if score := similarity(words, todoWords(sample.Todo.Description)); score > 0 {
+			matches = append(matches, scored{sample, score})
+		}
+	}
+	if len(matches) == 0 {
+		return Suggestion{}, false
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > maxSimilar {
+		matches = matches[:maxSimilar]
+	}
+
+	var suggestion Suggestion
+	spent := make([]time.Duration, 0, len(matches))
+	for _, match := range matches {
+		suggestion.Similar = append(suggestion.Similar, match.sample)
+		spent = append(spent, match.sample.Todo.TimeSpent)
+	}
+	// The median keeps one runaway todo from skewing the suggestion
+	slices.Sort(spent)
+	median := spent[len(spent)/2]
+	if len(spent)%2 == 0 {
+		median = (spent[len(spent)/2-1] + median) / 2
+	}
+	suggestion.Estimate = estimates.Round(median)
+	return suggestion, true
+}
+
+// todoWords splits a description into the set of lowercase words that
+// compare todos, ignoring due dates, "est:none" and very short words
+func todoWords(description string) map[string]bool {
+	description = dueRegex.ReplaceAllString(description, " ")
+	description = noEstimateRegex.ReplaceAllString(description, " ")
+
+	words := map[string]bool{}
+	for _, word := range wordRegex.FindAllString(strings.ToLower(description), -1) {
+		if len([]rune(word)) < 3 {
+			continue
+		}
+		words[word] = true
+	}
+	return words
+}
+
+// similarity is the Jaccard index of two word sets: the words they share
+// over all the words in either
+func similarity(a, b map[string]bool) float64 {
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return 0
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
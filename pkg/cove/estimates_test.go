@@ -0,0 +1,156 @@
+package cove
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateSamples(t *testing.T) {
+	completed := time.Date(2024, 5, 1, 17, 0, 0, 0, time.Local)
+	todos := []Todo{
+		{Description: "Review PR", LineNumber: 1, State: Done, Stars: 2, EstimatedTime: 10 * time.Minute, TimeSpent: 15 * time.Minute},
+		{Description: "Unstarred", LineNumber: 2, State: Done, EstimatedTime: 20 * time.Minute, TimeSpent: 5 * time.Minute},
+		{Description: "Still open", LineNumber: 3, Stars: 1, EstimatedTime: 5 * time.Minute, TimeSpent: 5 * time.Minute},
+		{Description: "Never timed", LineNumber: 4, State: Done, Stars: 1, EstimatedTime: 5 * time.Minute},
+		{Description: "Open ended est:none", LineNumber: 5, State: Done, Stars: 1, TimeSpent: 5 * time.Minute},
+	}
+	sessions := []Session{
+		{Description: "Review PR", File: "/tmp/todos.md", Line: 1, End: completed, Completed: true},
+		{Description: "Review PR", File: "/tmp/other.md", Line: 1, End: completed.Add(time.Hour), Completed: true},
+		{Description: "Review PR", File: "/tmp/todos.md", Line: 7, End: completed.Add(2 * time.Hour), Completed: true},
+	}
+
+	samples := EstimateSamples("/tmp/todos.md", todos, sessions)
+	if len(samples) != 1 {
+		t.Fatalf("got %d samples, want only the starred, done and timed todo", len(samples))
+	}
+	if samples[0].Todo.Description != "Review PR" || !samples[0].Completed.Equal(completed) {
+		t.Errorf("got %+v", samples[0])
+	}
+	if got := samples[0].Ratio(); got != 1.5 {
+		t.Errorf("got ratio %v, want 1.5", got)
+	}
+}
+
+// sample is a finished todo, with the tags and people of its description
+func sample(description string, estimate, spent time.Duration, completed time.Time) EstimateSample {
+	todo := Todo{Description: description, State: Done, EstimatedTime: estimate, TimeSpent: spent}
+	for _, tagMatch := range tagRegex.FindAllStringSubmatch(description, -1) {
+		todo.Tags = append(todo.Tags, tagMatch[1])
+	}
+	for _, personMatch := range personRegex.FindAllStringSubmatch(description, -1) {
+		todo.People = append(todo.People, personMatch[1])
+	}
+	return EstimateSample{Todo: todo, Completed: completed}
+}
+
+func TestNewAccuracyReport(t *testing.T) {
+	monday := time.Date(2024, 4, 29, 12, 0, 0, 0, time.Local)
+	samples := []EstimateSample{
+		sample("Fix login #bug @ana", 10*time.Minute, 20*time.Minute, monday),
+		sample("Fix build #bug #ci", 30*time.Minute, 15*time.Minute, monday.AddDate(0, 0, 7)),
+		sample("Write docs @ana", 20*time.Minute, 25*time.Minute, time.Time{}),
+	}
+	samples[0].Todo.Section = "Front end"
+
+	tests := []struct {
+		period string
+		groups func(AccuracyReport) []Accuracy
+		want   []Accuracy
+	}{
+		{
+			period: PeriodWeek,
+			groups: func(r AccuracyReport) []Accuracy { return []Accuracy{r.Overall} },
+			want:   []Accuracy{{Key: "all", Todos: 3, Estimated: time.Hour, Spent: time.Hour, Over: 2}},
+		},
+		{
+			period: PeriodWeek,
+			groups: func(r AccuracyReport) []Accuracy { return r.Tags },
+			want: []Accuracy{
+				{Key: "bug", Todos: 2, Estimated: 40 * time.Minute, Spent: 35 * time.Minute, Over: 1},
+				{Key: "ci", Todos: 1, Estimated: 30 * time.Minute, Spent: 15 * time.Minute},
+			},
+		},
+		{
+			period: PeriodWeek,
+			groups: func(r AccuracyReport) []Accuracy { return r.People },
+			want:   []Accuracy{{Key: "ana", Todos: 2, Estimated: 30 * time.Minute, Spent: 45 * time.Minute, Over: 2}},
+		},
+		{
+			period: PeriodWeek,
+			groups: func(r AccuracyReport) []Accuracy { return r.Sections },
+			want:   []Accuracy{{Key: "Front end", Todos: 1, Estimated: 10 * time.Minute, Spent: 20 * time.Minute, Over: 1}},
+		},
+		{
+			period: PeriodWeek,
+			groups: func(r AccuracyReport) []Accuracy { return r.Periods },
+			want: []Accuracy{
+				{Key: "2024-W18", Todos: 1, Estimated: 10 * time.Minute, Spent: 20 * time.Minute, Over: 1},
+				{Key: "2024-W19", Todos: 1, Estimated: 30 * time.Minute, Spent: 15 * time.Minute},
+			},
+		},
+		{
+			period: PeriodMonth,
+			groups: func(r AccuracyReport) []Accuracy { return r.Periods },
+			want: []Accuracy{
+				{Key: "2024-04", Todos: 1, Estimated: 10 * time.Minute, Spent: 20 * time.Minute, Over: 1},
+				{Key: "2024-05", Todos: 1, Estimated: 30 * time.Minute, Spent: 15 * time.Minute},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		report := NewAccuracyReport(samples, tt.period)
+		got := tt.groups(report)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.period, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %+v, want %+v", tt.period, got[i], tt.want[i])
+			}
+		}
+	}
+
+	if got := (Accuracy{}).Ratio(); got != 0 {
+		t.Errorf("empty accuracy has ratio %v", got)
+	}
+}
+
+func TestSuggestEstimate(t *testing.T) {
+	samples := []EstimateSample{
+		sample("Review PR for login #review", 10*time.Minute, 12*time.Minute, time.Time{}),
+		sample("Review PR for billing #review", 10*time.Minute, 18*time.Minute, time.Time{}),
+		sample("Review PR for search #review", 10*time.Minute, 90*time.Minute, time.Time{}),
+		sample("Plan sprint due:2024-05-01", 30*time.Minute, 40*time.Minute, time.Time{}),
+		sample("Call the bank est:none", 0, 7*time.Minute, time.Time{}),
+	}
+
+	tests := []struct {
+		description string
+		want        time.Duration
+		similar     int
+		ok          bool
+	}{
+		// The median of 12m, 18m and 90m, rounded up to stars
+		{description: "Review PR for payments #review", want: 20 * time.Minute, similar: 3, ok: true},
+		{description: "Plan sprint", want: 40 * time.Minute, similar: 1, ok: true},
+		{description: "Call the bank again", want: 10 * time.Minute, similar: 1, ok: true},
+		// Sharing only a due date doesn't make todos similar
+		{description: "Buy milk due:2024-05-01", ok: false},
+		{description: "Water plants", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got, ok := SuggestEstimate(samples, tt.description, DefaultEstimates())
+			if ok != tt.ok {
+				t.Fatalf("got ok %v, want %v", ok, tt.ok)
+			}
+			if got.Estimate != tt.want || len(got.Similar) != tt.similar {
+				t.Errorf("got %v from %d todos, want %v from %d", got.Estimate, len(got.Similar), tt.want, tt.similar)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package cove
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TodoRecord is the machine-readable form of a Todo used by exports
+type TodoRecord struct {
+	Description      string   `json:"description"`
+	State            string   `json:"state"`
+	EstimateSeconds  int64    `json:"estimate_seconds"`
+	TimeSpentSeconds int64    `json:"time_spent_seconds"`
+	File             string   `json:"file"`
+	Line             int      `json:"line"`
+	Tags             []string `json:"tags"`
+	Due              string   `json:"due,omitempty"`
+}
+
+// SessionRecord is the machine-readable form of a Session used by exports
+type SessionRecord struct {
+	Description     string    `json:"description"`
+	File            string    `json:"file"`
+	Line            int       `json:"line"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds int64     `json:"duration_seconds"`
+	OvertimeSeconds int64     `json:"overtime_seconds"`
+	Completed       bool      `json:"completed"`
+}
+
+// Export is the document written by ExportJSON
+type Export struct {
+	Todos    []TodoRecord    `json:"todos"`
+	Sessions []SessionRecord `json:"sessions"`
+}
+
+func NewTodoRecord(todo Todo, filename string) TodoRecord {
+	tags := todo.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	due := ""
+	if !todo.Due.IsZero() {
+		due = todo.Due.Format("2006-01-02")
+	}
+	return TodoRecord{
+		Description:      todo.Description,
+		State:            todo.State.String(),
+		EstimateSeconds:  int64(todo.EstimatedTime.Seconds()),
+		TimeSpentSeconds: int64(todo.TimeSpent.Seconds()),
+		File:             filename,
+		Line:             todo.LineNumber,
+		Tags:             tags,
+		Due:              due,
+	}
+}
+
+func NewSessionRecord(session Session) SessionRecord {
+	return SessionRecord{
+		Description:     session.Description,
+		File:            session.File,
+		Line:            session.Line,
+		Start:           session.Start,
+		End:             session.End,
+		DurationSeconds: int64(session.Duration.Seconds()),
+		OvertimeSeconds: int64(session.Overtime.Seconds()),
+		Completed:       session.Completed,
+	}
+}
+
+// ExportJSON writes todos and sessions as a single JSON document
+func ExportJSON(w io.Writer, filename string, todos []Todo, sessions []Session) error {
+	export := Export{
+		Todos:    make([]TodoRecord, 0, len(todos)),
+		Sessions: make([]SessionRecord, 0, len(sessions)),
+	}
+	for _, todo := range todos {
+		export.Todos = append(export.Todos, NewTodoRecord(todo, filename))
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, NewSessionRecord(session))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return fmt.Errorf("failed to encode export: %w", err)
+	}
+	return nil
+}
+
+// ExportTodosCSV writes one row per todo with a header row.
+// Tags are joined with spaces since they cannot contain whitespace.
+func ExportTodosCSV(w io.Writer, filename string, todos []Todo) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"description", "state", "estimate_seconds", "time_spent_seconds", "file", "line", "tags"})
+
+	for _, todo := range todos {
+		record := NewTodoRecord(todo, filename)
+		writer.Write([]string{
+			record.Description,
+			record.State,
+			strconv.FormatInt(record.EstimateSeconds, 10),
+			strconv.FormatInt(record.TimeSpentSeconds, 10),
+			record.File,
+			strconv.Itoa(record.Line),
+			strings.Join(record.Tags, " "),
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	return nil
+}
+
+// ExportSessionsCSV writes one row per session with a header row
+func ExportSessionsCSV(w io.Writer, sessions []Session) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"description", "file", "line", "start", "end", "duration_seconds", "completed", "overtime_seconds"})
+
+	for _, session := range sessions {
+		record := NewSessionRecord(session)
+		writer.Write([]string{
+			record.Description,
+			record.File,
+			strconv.Itoa(record.Line),
+			record.Start.Format(time.RFC3339),
+			record.End.Format(time.RFC3339),
+			strconv.FormatInt(record.DurationSeconds, 10),
+			strconv.FormatBool(record.Completed),
+			strconv.FormatInt(record.OvertimeSeconds, 10),
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	return nil
+}
+
+// ActiveRecord is the machine-readable form of a running ActiveSession
+type ActiveRecord struct {
+	Description      string    `json:"description"`
+	File             string    `json:"file"`
+	Line             int       `json:"line"`
+	Started          time.Time `json:"started"`
+	ElapsedSeconds   int64     `json:"elapsed_seconds"`
+	EstimateSeconds  int64     `json:"estimate_seconds"`
+	RemainingSeconds int64     `json:"remaining_seconds"`
+	OvertimeSeconds  int64     `json:"overtime_seconds"`
+	Paused           bool      `json:"paused"`
+}
+
+func NewActiveRecord(active *ActiveSession) ActiveRecord {
+	return ActiveRecord{
+		Description:      active.Description,
+		File:             active.File,
+		Line:             active.Line,
+		Started:          active.Started,
+		ElapsedSeconds:   int64(active.Elapsed().Seconds()),
+		EstimateSeconds:  int64(active.Estimate.Seconds()),
+		RemainingSeconds: int64(active.Remaining().Seconds()),
+		OvertimeSeconds:  int64(active.Overtime().Seconds()),
+		Paused:           active.Paused,
+	}
+}
@@ -12,8 +12,22 @@ import (
 var todoRegex = regexp.MustCompile(`^[\s]*-\s+\[([x\s*])\]\s+(.+)$`)
 var starRegex = regexp.MustCompile(`\*+`)
 var timeRegex = regexp.MustCompile(`\(took (\d+)m\)`)
+var tagRegex = regexp.MustCompile(`(?:^|\s)#([\w-]+)`)
+var dueRegex = regexp.MustCompile(`(?:^|\s)due:(\d{4}-\d{2}-\d{2})`)
+var noEstimateRegex = regexp.MustCompile(`(?:^|\s)est:none(?:\s|$)`)
+var personRegex = regexp.MustCompile(`(?:^|\s)@([\w-]+)`)
+var headingRegex = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*$`)
+var indentRegex = regexp.MustCompile(`^(\s*)`)
 
+// ReadTodos reads the todos of a markdown file, turning their star hints
+// into estimates on the default scale
 func ReadTodos(filename string) ([]Todo, error) {
+	return ReadTodosWithEstimates(filename, DefaultEstimates())
+}
+
+// ReadTodosWithEstimates reads the todos of a markdown file, turning their
+// star hints into estimates on the given scale
+func ReadTodosWithEstimates(filename string, estimates Estimates) ([]Todo, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
@@ -23,10 +37,15 @@ func ReadTodos(filename string) ([]Todo, error) {
 	var todos []Todo
 	scanner := bufio.NewScanner(file)
 	lineNumber := 0
+	section := ""
 
 	for scanner.Scan() {
 		lineNumber++
 		line := scanner.Text()
+		if heading := headingRegex.FindStringSubmatch(line); heading != nil {
+			section = heading[1]
+			continue
+		}
 		if match := todoRegex.FindStringSubmatch(line); match != nil {
 			checkbox := strings.TrimSpace(match[1])
 			description := strings.TrimSpace(match[2])
@@ -45,12 +64,14 @@ func ReadTodos(filename string) ([]Todo, error) {
 			var todo Todo
 			if starMatch := starRegex.FindString(description); starMatch != "" {
 				starCount := len(starMatch)
-				estimatedMinutes := starCount * 5 // each star = 5 minutes
 				// Remove stars from description
 				cleanDescription := strings.TrimSpace(starRegex.ReplaceAllString(description, ""))
-				todo = NewTodoWithEstimate(cleanDescription, estimatedMinutes)
+				todo = NewTodo(cleanDescription)
+				todo.Stars = starCount
+				todo.EstimatedTime = time.Duration(starCount) * estimates.Star
 			} else {
 				todo = NewTodo(description)
+				todo.EstimatedTime = estimates.Default
 			}
 			
 			// Set state based on checkbox
@@ -69,6 +90,30 @@ func ReadTodos(filename string) ([]Todo, error) {
 			todo.OriginalLine = line
 			todo.LineNumber = lineNumber
 			
+			// Collect #tags, leaving them in the description
+			for _, tagMatch := range tagRegex.FindAllStringSubmatch(todo.Description, -1) {
+				todo.Tags = append(todo.Tags, tagMatch[1])
+			}
+			
+			// Parse a due date like "due:2024-05-01", also left in the description
+			if dueMatch := dueRegex.FindStringSubmatch(todo.Description); dueMatch != nil {
+				if due, err := time.ParseInLocation("2006-01-02", dueMatch[1], time.Local); err == nil {
+					todo.Due = due
+				}
+			}
+			
+			// Collect @people the todo is assigned to, also left in the
+			// description
+			for _, personMatch := range personRegex.FindAllStringSubmatch(todo.Description, -1) {
+				todo.People = append(todo.People, personMatch[1])
+			}
+			todo.Section = section
+			
+			// "est:none" marks open-ended todos, timed with a stopwatch
+			if noEstimateRegex.MatchString(todo.Description) {
+				todo.EstimatedTime = 0
+			}
+			
 			todos = append(todos, todo)
 		}
 	}
@@ -101,41 +146,7 @@ func WriteTodos(filename string, todos []Todo) error {
 	// Update lines with completed todos
 	for _, todo := range todos {
 		if todo.LineNumber > 0 && todo.LineNumber <= len(allLines) {
-			lineIndex := todo.LineNumber - 1
-			
-			// Generate the updated line
-			checkbox := " "
-			if todo.State == Done {
-				checkbox = "x"
-			}
-			
-			// Format time spent
-			timeSpentStr := ""
-			if todo.TimeSpent > 0 {
-				minutes := int(todo.TimeSpent.Minutes())
-				if minutes > 0 {
-					timeSpentStr = fmt.Sprintf(" (took %dm)", minutes)
-				}
-			}
-			
-			// Reconstruct the line with stars if they were originally present
-			stars := ""
-			if todo.EstimatedTime != 20*time.Minute {
-				starCount := int(todo.EstimatedTime.Minutes()) / 5
-				stars = strings.Repeat("*", starCount)
-				if stars != "" {
-					stars = " " + stars
-				}
-			}
-			
-			// Extract indentation from original line
-			indent := ""
-			if match := regexp.MustCompile(`^(\s*)`).FindStringSubmatch(todo.OriginalLine); match != nil {
-				indent = match[1]
-			}
-			
-			newLine := fmt.Sprintf("%s- [%s] %s%s%s", indent, checkbox, todo.Description, stars, timeSpentStr)
-			allLines[lineIndex] = newLine
+			allLines[todo.LineNumber-1] = formatTodoLine(todo)
 		}
 	}
 
@@ -153,4 +164,65 @@ func WriteTodos(filename string, todos []Todo) error {
 	}
 
 	return nil
+}
+
+// AppendTodos adds new todo lines to the end of the file
+func AppendTodos(filename string, todos []Todo) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file for reading: %w", err)
+	}
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open file for appending: %w", err)
+	}
+	defer file.Close()
+
+	// Don't glue the first new todo onto an unterminated last line
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		if _, err := fmt.Fprintln(file); err != nil {
+			return fmt.Errorf("error writing line: %w", err)
+		}
+	}
+
+	for _, todo := range todos {
+		if _, err := fmt.Fprintln(file, formatTodoLine(todo)); err != nil {
+			return fmt.Errorf("error writing line: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// formatTodoLine renders a todo back into its markdown form
+func formatTodoLine(todo Todo) string {
+	// Generate the updated line
+	checkbox := " "
+	if todo.State == Done {
+		checkbox = "x"
+	}
+	
+	// Format time spent
+	timeSpentStr := ""
+	if todo.TimeSpent > 0 {
+		minutes := int(todo.TimeSpent.Minutes())
+		if minutes > 0 {
+			timeSpentStr = fmt.Sprintf(" (took %dm)", minutes)
+		}
+	}
+	
+	// Reconstruct the line with stars if they were originally present
+	stars := ""
+	if todo.Stars > 0 {
+		stars = " " + strings.Repeat("*", todo.Stars)
+	}
+	
+	// Extract indentation from original line
+	indent := ""
+	if match := indentRegex.FindStringSubmatch(todo.OriginalLine); match != nil {
+		indent = match[1]
+	}
+	
+	return fmt.Sprintf("%s- [%s] %s%s%s", indent, checkbox, todo.Description, stars, timeSpentStr)
 }
\ No newline at end of file
@@ -0,0 +1,140 @@
+package cove
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTodos(t *testing.T) {
+	estimates := Estimates{Star: 10 * time.Minute, Default: 30 * time.Minute}
+	tests := []struct {
+		name  string
+		line  string
+		want  Todo
+		check func(t *testing.T, todo Todo)
+	}{
+		{
+			name: "plain",
+			line: "- [ ] Write report",
+			want: Todo{Description: "Write report", EstimatedTime: 30 * time.Minute},
+		},
+		{
+			name: "stars and time",
+			line: "- [x] Review PR *** (took 12m)",
+			want: Todo{Description: "Review PR", State: Done, Stars: 3, EstimatedTime: 30 * time.Minute, TimeSpent: 12 * time.Minute},
+		},
+		{
+			name: "in progress counts as open",
+			line: "  - [*] Nested task *",
+			want: Todo{Description: "Nested task", Stars: 1, EstimatedTime: 10 * time.Minute},
+		},
+		{
+			name: "tags, people and due date stay in the description",
+			line: "- [ ] Fix login #bug #auth @ana due:2024-05-01 **",
+			want: Todo{
+				Description:   "Fix login #bug #auth @ana due:2024-05-01",
+				Stars:         2,
+				EstimatedTime: 20 * time.Minute,
+				Tags:          []string{"bug", "auth"},
+				People:        []string{"ana"},
+				Due:           time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+			},
+		},
+		{
+			name: "no estimate",
+			line: "- [ ] Explore est:none",
+			want: Todo{Description: "Explore est:none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "todos.md", "# Work\n"+tt.line+"\n")
+			todos, err := ReadTodosWithEstimates(path, estimates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(todos) != 1 {
+				t.Fatalf("got %d todos, want 1", len(todos))
+			}
+			got := todos[0]
+			want := tt.want
+			want.OriginalLine = tt.line
+			want.LineNumber = 2
+			want.Section = "Work"
+			if got.Description != want.Description || got.State != want.State ||
+				got.Stars != want.Stars || got.EstimatedTime != want.EstimatedTime ||
+				got.TimeSpent != want.TimeSpent || !got.Due.Equal(want.Due) ||
+				got.OriginalLine != want.OriginalLine || got.LineNumber != want.LineNumber ||
+				got.Section != want.Section || !slices.Equal(got.Tags, want.Tags) ||
+				!slices.Equal(got.People, want.People) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteTodosRoundTrip(t *testing.T) {
+	content := strings.Join([]string{
+		"# Today",
+		"",
+		"- [ ] Write report",
+		"- [x] Review PR *** (took 12m)",
+		"  - [ ] Nested task *",
+		"- [ ] Fix login #bug @ana due:2024-05-01 **",
+		"- [ ] Explore est:none (took 5m)",
+		"Some notes",
+		"",
+	}, "\n")
+	path := writeFile(t, "todos.md", content)
+
+	todos, err := ReadTodos(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTodos(path, todos); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != content {
+		t.Errorf("round trip changed the file:\n%s\nwant:\n%s", got, content)
+	}
+}
+
+func TestFormatTodoLine(t *testing.T) {
+	tests := []struct {
+		name string
+		todo Todo
+		want string
+	}{
+		{
+			name: "done with time",
+			todo: Todo{Description: "Write report", State: Done, TimeSpent: 25 * time.Minute},
+			want: "- [x] Write report (took 25m)",
+		},
+		{
+			name: "stars and indent",
+			todo: Todo{Description: "Fix login #bug due:2024-05-01", Stars: 2, OriginalLine: "    - [ ] Fix login"},
+			want: "    - [ ] Fix login #bug due:2024-05-01 **",
+		},
+		{
+			name: "estimate without stars",
+			todo: NewTodoWithEstimate("Plan the week", 12),
+			want: "- [ ] Plan the week ***",
+		},
+		{
+			name: "less than a minute is left out",
+			todo: Todo{Description: "Explore est:none", TimeSpent: 30 * time.Second},
+			want: "- [ ] Explore est:none",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTodoLine(tt.todo); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package cove
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter narrows todos down by state, tag, section and person. It is
+// written as a query like `state:open tag:bug section:"Front end"`: a todo
+// has to match every field given, and any of the values when a field is
+// given more than once.
+type Filter struct {
+	Name     string
+	Query    string
+	States   []string
+	Tags     []string
+	Sections []string
+	People   []string
+}
+
+// filterFields are the fields a filter query can use
+var filterFields = []string{"state", "tag", "section", "person"}
+
+var filterTermRegex = regexp.MustCompile(`^(\w+):("[^"]*"|\S+)(?:\s+|$)`)
+
+// ParseFilter reads a filter query
+func ParseFilter(query string) (Filter, error) {
+	filter := Filter{Query: query}
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		match := filterTermRegex.FindStringSubmatch(rest)
+		if match == nil {
+			return filter, fmt.Errorf("%q is not a field:value term", strings.Fields(rest)[0])
+		}
+		rest = rest[len(match[0]):]
+		value := strings.ToLower(strings.Trim(match[2], `"`))
+
+		switch match[1] {
+		case "state":
+			if value != Open.String() && value != Done.String() {
+				return filter, fmt.Errorf("state must be open or done, not %q", value)
+			}
+			filter.States = append(filter.States, value)
+		case "tag":
+			filter.Tags = append(filter.Tags, strings.TrimPrefix(value, "#"))
+		case "section":
+			filter.Sections = append(filter.Sections, value)
+		case "person":
+			filter.People = append(filter.People, strings.TrimPrefix(value, "@"))
+		default:
+			return filter, fmt.Errorf("unknown field %q (have: %s)", match[1], strings.Join(filterFields, ", "))
+		}
+	}
+	return filter, nil
+}
+
+// Match reports whether todo passes the filter. Values are compared
+// without regard to case.
+func (f Filter) Match(todo Todo) bool {
+	if len(f.States) > 0 && !contains(f.States, todo.State.String()) {
+		return false
+	}
+	if len(f.Sections) > 0 && !contains(f.Sections, strings.ToLower(todo.Section)) {
+		return false
+	}
+	if len(f.Tags) > 0 && !containsAny(f.Tags, todo.Tags) {
+		return false
+	}
+	if len(f.People) > 0 && !containsAny(f.People, todo.People) {
+		return false
+	}
+	return true
+}
+
+// containsAny reports whether any of values, lowercased, is in want
+func containsAny(want, values []string) bool {
+	for _, value := range values {
+		if contains(want, strings.ToLower(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTodos returns the todos that pass the filter
+func FilterTodos(todos []Todo, filter Filter) []Todo {
+	var result []Todo
+	for _, todo := range todos {
+		if filter.Match(todo) {
+			result = append(result, todo)
+		}
+	}
+	return result
+}
+
+// savedFilters parses the [filters] table of the config, sorted by name
+func savedFilters(queries map[string]string) []Filter {
+	var filters []Filter
+	for _, name := range sortedKeys(queries) {
+		filter, err := ParseFilter(queries[name])
+		if err != nil {
+			// The config is validated when it's loaded
+			continue
+		}
+		filter.Name = name
+		filters = append(filters, filter)
+	}
+	return filters
+}
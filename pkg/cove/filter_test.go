@@ -0,0 +1,79 @@
+package cove
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    Filter
+		wantErr bool
+	}{
+		{query: "", want: Filter{}},
+		{query: "state:open", want: Filter{States: []string{"open"}}},
+		{query: "state:DONE tag:#Bug tag:ui", want: Filter{States: []string{"done"}, Tags: []string{"bug", "ui"}}},
+		{query: `section:"Front end" person:@ana`, want: Filter{Sections: []string{"front end"}, People: []string{"ana"}}},
+		{query: "  tag:bug  ", want: Filter{Tags: []string{"bug"}}},
+		{query: "state:waiting", wantErr: true},
+		{query: "owner:me", wantErr: true},
+		{query: "bug", wantErr: true},
+		{query: `section:"Front end`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := ParseFilter(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Query != tt.query || !slices.Equal(got.States, tt.want.States) ||
+				!slices.Equal(got.Tags, tt.want.Tags) || !slices.Equal(got.Sections, tt.want.Sections) ||
+				!slices.Equal(got.People, tt.want.People) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	todos := []Todo{
+		{Description: "Fix login", Tags: []string{"Bug"}, Section: "Front end", People: []string{"ana"}},
+		{Description: "Fix build", Tags: []string{"bug", "ci"}, Section: "Back end", State: Done},
+		{Description: "Write docs", Section: "Front end", People: []string{"ben"}},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: []string{"Fix login", "Fix build", "Write docs"}},
+		{query: "state:open", want: []string{"Fix login", "Write docs"}},
+		{query: "tag:bug", want: []string{"Fix login", "Fix build"}},
+		{query: "tag:bug state:done", want: []string{"Fix build"}},
+		{query: "tag:ci tag:docs", want: []string{"Fix build"}},
+		{query: `section:"front END"`, want: []string{"Fix login", "Write docs"}},
+		{query: "person:ana person:ben", want: []string{"Fix login", "Write docs"}},
+		{query: "person:cy", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filter, err := ParseFilter(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, todo := range FilterTodos(todos, filter) {
+				got = append(got, todo.Description)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package cove
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Enabled reports whether a daily goal is set
+func (g GoalConfig) Enabled() bool {
+	return g.Pomodoros > 0 || g.Focus.Duration > 0
+}
+
+// Fraction is how much of the goal a day's progress covers, from 0 up; it
+// goes past 1 once the goal is beaten
+func (g GoalConfig) Fraction(day DayProgress) float64 {
+	if g.Pomodoros > 0 {
+		return float64(day.Pomodoros) / float64(g.Pomodoros)
+	}
+	if g.Focus.Duration > 0 {
+		return float64(day.Focus) / float64(g.Focus.Duration)
+	}
+	return 0
+}
+
+// Met reports whether a day reached the goal
+func (g GoalConfig) Met(day DayProgress) bool {
+	return g.Enabled() && g.Fraction(day) >= 1
+}
+
+// DayProgress is the work recorded on one day
+type DayProgress struct {
+	Focus     time.Duration
+	Pomodoros int
+}
+
+// FocusLog adds up the session history day by day. A session counts
+// towards the day it started on. A nil FocusLog is empty.
+type FocusLog struct {
+	days map[string]DayProgress
+}
+
+func NewFocusLog(sessions []Session) *FocusLog {
+	log := &FocusLog{days: map[string]DayProgress{}}
+	for _, session := range sessions {
+		log.Add(session)
+	}
+	return log
+}
+
+// LoadFocusLog reads the history file into a FocusLog
+func LoadFocusLog(historyPath string) (*FocusLog, error) {
+	sessions, err := ReadSessions(historyPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewFocusLog(sessions), nil
+}
+
+// Add counts a session just recorded
+func (l *FocusLog) Add(session Session) {
+	if l == nil {
+		return
+	}
+	key := dayKey(session.Start)
+	day := l.days[key]
+	day.Focus += session.Duration
+	// The countdown's ticks can leave a full interval a hair short
+	if session.Estimate > 0 && session.Duration+time.Second >= session.Estimate {
+		day.Pomodoros++
+	}
+	l.days[key] = day
+}
+
+// Day returns the progress of the day t falls on
+func (l *FocusLog) Day(t time.Time) DayProgress {
+	if l == nil {
+		return DayProgress{}
+	}
+	return l.days[dayKey(t)]
+}
+
+// Streak counts the days in a row, up to today, that met the goal. Today
+// only breaks the streak once it is over, so a streak carried from
+// yesterday still shows in the morning.
+func (l *FocusLog) Streak(goal GoalConfig, today time.Time) int {
+	if !goal.Enabled() {
+		return 0
+	}
+	day := today
+	if !goal.Met(l.Day(day)) {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for goal.Met(l.Day(day)) {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+func dayKey(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
+
+// formatGoal describes progress towards the goal, like "2h10m0s of 5h0m0s"
+// or "3 of 8 pomodoros"
+func formatGoal(goal GoalConfig, day DayProgress) string {
+	if goal.Pomodoros > 0 {
+		return fmt.Sprintf("%d of %d pomodoros", day.Pomodoros, goal.Pomodoros)
+	}
+	return fmt.Sprintf("%v of %v", day.Focus.Truncate(time.Minute), goal.Focus.Duration)
+}
+
+// goalBarWidth is how many cells the progress bar has
+const goalBarWidth = 10
+
+// goalView shows today's progress towards the goal and the streak, adding
+// live, the time of a session still being timed. It is empty without a goal.
+func (m TodoSelectorModel) goalView(live time.Duration) string {
+	if !m.goal.Enabled() {
+		return ""
+	}
+	now := time.Now()
+	today := m.focus.Day(now)
+	today.Focus += live
+
+	filled := min(int(m.goal.Fraction(today)*goalBarWidth), goalBarWidth)
+	doneStyle := lipgloss.NewStyle().Foreground(m.theme.Highlight)
+	todoStyle := lipgloss.NewStyle().Foreground(m.theme.Subtle)
+	textStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+
+	var s strings.Builder
+	s.WriteString(textStyle.Render("🎯 " + formatGoal(m.goal, today) + " today  "))
+	s.WriteString(doneStyle.Render(strings.Repeat("▰", filled)))
+	s.WriteString(todoStyle.Render(strings.Repeat("▱", goalBarWidth-filled)))
+	if m.goal.Met(today) {
+		s.WriteString(doneStyle.Render("  ✅"))
+	}
+	if streak := m.focus.Streak(m.goal, now); streak > 0 {
+		noun := "days"
+		if streak == 1 {
+			noun = "day"
+		}
+		s.WriteString(textStyle.Render(fmt.Sprintf("  🔥 %d %s in a row", streak, noun)))
+	}
+	return s.String()
+}
@@ -0,0 +1,80 @@
+package cove
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFocusLogStreak(t *testing.T) {
+	today := time.Date(2024, 5, 10, 15, 0, 0, 0, time.Local)
+	// pomodoro is a full 25 minute interval on the day offset days from today
+	pomodoro := func(offset int) Session {
+		start := today.AddDate(0, 0, offset).Add(-time.Hour)
+		return Session{Start: start, End: start.Add(25 * time.Minute), Duration: 25 * time.Minute, Estimate: 25 * time.Minute}
+	}
+	// focus is time worked without an estimate
+	focus := func(offset int, d time.Duration) Session {
+		start := today.AddDate(0, 0, offset).Add(-3 * time.Hour)
+		return Session{Start: start, End: start.Add(d), Duration: d}
+	}
+	twoPomodoros := GoalConfig{Pomodoros: 2}
+	twoHours := GoalConfig{Focus: Duration{2 * time.Hour}}
+
+	tests := []struct {
+		name     string
+		goal     GoalConfig
+		sessions []Session
+		want     int
+	}{
+		{name: "no goal", goal: GoalConfig{}, sessions: []Session{pomodoro(0), pomodoro(0)}, want: 0},
+		{name: "nothing yet", goal: twoPomodoros, want: 0},
+		{name: "today met", goal: twoPomodoros, sessions: []Session{pomodoro(0), pomodoro(0)}, want: 1},
+		{
+			name:     "today not met yet keeps the streak",
+			goal:     twoPomodoros,
+			sessions: []Session{pomodoro(-2), pomodoro(-2), pomodoro(-1), pomodoro(-1), pomodoro(0)},
+			want:     2,
+		},
+		{
+			name:     "today met extends the streak",
+			goal:     twoPomodoros,
+			sessions: []Session{pomodoro(-1), pomodoro(-1), pomodoro(0), pomodoro(0)},
+			want:     2,
+		},
+		{
+			name:     "a missed day breaks it",
+			goal:     twoPomodoros,
+			sessions: []Session{pomodoro(-3), pomodoro(-3), pomodoro(-1)},
+			want:     0,
+		},
+		{
+			name: "short sessions are not pomodoros",
+			goal: twoPomodoros,
+			sessions: []Session{
+				pomodoro(-1),
+				{Start: today.AddDate(0, 0, -1), Duration: 10 * time.Minute, Estimate: 25 * time.Minute},
+			},
+			want: 0,
+		},
+		{
+			name:     "focus time adds up",
+			goal:     twoHours,
+			sessions: []Session{focus(-2, 2*time.Hour), focus(-1, time.Hour), focus(-1, 70*time.Minute)},
+			want:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := NewFocusLog(tt.sessions)
+			if got := log.Streak(tt.goal, today); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	var empty *FocusLog
+	if got := empty.Streak(twoPomodoros, today); got != 0 {
+		t.Errorf("nil log has a streak of %d", got)
+	}
+}
@@ -0,0 +1,111 @@
+package cove
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// Hooks runs the user's shell commands when events happen. Each command
+// gets the event as JSON on stdin and its details in COVE_* environment
+// variables.
+type Hooks struct {
+	commands map[string]string
+	// Output receives the commands' stdout and stderr; nil discards it,
+	// which the TUI needs to keep its screen intact
+	Output io.Writer
+}
+
+// NewHooks runs commands, which maps event types to shell commands
+func NewHooks(commands map[string]string) *Hooks {
+	return &Hooks{commands: commands}
+}
+
+// Run starts the command for event, if there is one, without waiting for it
+func (h *Hooks) Run(event Event) {
+	if h == nil || h.commands[event.Type] == "" {
+		return
+	}
+
+	// The event is written to a pipe before the command starts so it still
+	// arrives if this process exits first, as short-lived CLI commands do
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	stdin, input, err := os.Pipe()
+	if err != nil {
+		h.report(event, err)
+		return
+	}
+	defer stdin.Close()
+	if _, err := input.Write(append(data, '\n')); err != nil {
+		input.Close()
+		h.report(event, err)
+		return
+	}
+	input.Close()
+
+	cmd := exec.Command("sh", "-c", h.commands[event.Type])
+	cmd.Env = append(os.Environ(), hookEnv(event)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = h.Output
+	cmd.Stderr = h.Output
+	if err := cmd.Start(); err != nil {
+		h.report(event, err)
+		return
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			h.report(event, err)
+		}
+	}()
+}
+
+func (h *Hooks) report(event Event, err error) {
+	if h.Output != nil {
+		fmt.Fprintf(h.Output, "Hook for %s failed: %v\n", event.Type, err)
+	}
+}
+
+// hookEnv describes the event in environment variables. Durations are in
+// whole seconds.
+func hookEnv(event Event) []string {
+	env := []string{
+		"COVE_EVENT=" + event.Type,
+		"COVE_TIME=" + event.Time.Format(time.RFC3339),
+	}
+	seconds := func(name string, value float64) string {
+		return name + "=" + strconv.Itoa(int(value))
+	}
+
+	switch {
+	case event.Active != nil:
+		env = append(env,
+			"COVE_TODO="+event.Active.Description,
+			"COVE_FILE="+event.Active.File,
+			"COVE_LINE="+strconv.Itoa(event.Active.Line),
+			seconds("COVE_ELAPSED", event.Active.Elapsed().Seconds()),
+			seconds("COVE_ESTIMATE", event.Active.Estimate.Seconds()),
+			"COVE_PAUSED="+strconv.FormatBool(event.Active.Paused),
+		)
+	case event.Session != nil:
+		env = append(env,
+			"COVE_TODO="+event.Session.Description,
+			"COVE_FILE="+event.Session.File,
+			"COVE_LINE="+strconv.Itoa(event.Session.Line),
+			seconds("COVE_ELAPSED", event.Session.Duration.Seconds()),
+			"COVE_COMPLETED="+strconv.FormatBool(event.Session.Completed),
+		)
+	case event.File != "":
+		env = append(env, "COVE_FILE="+event.File)
+		if event.Todo != "" {
+			env = append(env, "COVE_TODO="+event.Todo, "COVE_LINE="+strconv.Itoa(event.Line))
+		}
+	}
+	return env
+}
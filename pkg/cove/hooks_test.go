@@ -0,0 +1,142 @@
+package cove
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHookEnv(t *testing.T) {
+	now := time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		event Event
+		want  []string
+	}{
+		{
+			name: "running session",
+			event: Event{Type: EventPaused, Time: now, Active: &ActiveSession{
+				File: "/todos.md", Description: "Write report", Line: 2, Estimate: 25 * time.Minute,
+				Started: now.Add(-10 * time.Minute), Worked: 10 * time.Minute, Paused: true,
+			}},
+			want: []string{
+				"COVE_EVENT=paused", "COVE_TIME=2024-04-30T09:00:00Z", "COVE_TODO=Write report", "COVE_FILE=/todos.md",
+				"COVE_LINE=2", "COVE_ELAPSED=600", "COVE_ESTIMATE=1500", "COVE_PAUSED=true",
+			},
+		},
+		{
+			name: "recorded session",
+			event: Event{Type: EventStopped, Time: now, Session: &Session{
+				Description: "Write report", File: "/todos.md", Line: 2, Duration: 90 * time.Second,
+			}},
+			want: []string{
+				"COVE_EVENT=stopped", "COVE_TIME=2024-04-30T09:00:00Z", "COVE_TODO=Write report", "COVE_FILE=/todos.md",
+				"COVE_LINE=2", "COVE_ELAPSED=90", "COVE_COMPLETED=false",
+			},
+		},
+		{
+			name:  "done without a session",
+			event: Event{Type: EventDone, Time: now, File: "/todos.md", Todo: "Review docs", Line: 5},
+			want: []string{
+				"COVE_EVENT=done", "COVE_TIME=2024-04-30T09:00:00Z", "COVE_FILE=/todos.md", "COVE_TODO=Review docs", "COVE_LINE=5",
+			},
+		},
+		{
+			name:  "reloaded",
+			event: Event{Type: EventReloaded, Time: now, File: "/todos.md"},
+			want:  []string{"COVE_EVENT=reloaded", "COVE_TIME=2024-04-30T09:00:00Z", "COVE_FILE=/todos.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hookEnv(tt.event); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// hookOutput returns hooks that write each event's environment to a file
+// named after the event in dir, and its JSON to the same name with .json
+func hookOutput(dir string, events ...string) *Hooks {
+	commands := make(map[string]string)
+	for _, event := range events {
+		out := filepath.Join(dir, event)
+		commands[event] = `cat > "` + out + `.json" && env | grep ^COVE_ > "` + out + `.tmp" && mv "` + out + `.tmp" "` + out + `"`
+	}
+	return NewHooks(commands)
+}
+
+// waitForHook returns the environment and event the hook for event wrote
+// to dir
+func waitForHook(t *testing.T, dir, event string) (string, Event) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if env, err := os.ReadFile(filepath.Join(dir, event)); err == nil {
+			var got Event
+			if err := json.Unmarshal([]byte(readFile(t, filepath.Join(dir, event+".json"))), &got); err != nil {
+				t.Fatalf("the %s hook's stdin isn't the event: %v", event, err)
+			}
+			return string(env), got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the %s hook didn't run", event)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHooksRun(t *testing.T) {
+	dir := t.TempDir()
+	engine, path := newTestEngine(t)
+	engine.WithHooks(hookOutput(dir, EventDone))
+
+	// A todo that isn't being timed is still described
+	if _, err := engine.Done(path, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	env, event := waitForHook(t, dir, EventDone)
+	for _, want := range []string{"COVE_EVENT=done\n", "COVE_TODO=Review docs\n", "COVE_LINE=5\n", "COVE_FILE=" + absPath(path) + "\n"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("hook environment does not have %q:\n%s", want, env)
+		}
+	}
+	if event.Type != EventDone || event.Todo != "Review docs" || event.Line != 5 || event.File != absPath(path) {
+		t.Errorf("got event %+v", event)
+	}
+}
+
+func TestTimerSwitchHook(t *testing.T) {
+	dir := t.TempDir()
+	selector, _ := newTestSelector(t)
+	selector.hooks = hookOutput(dir, EventStopped, EventSwitched)
+
+	m := worked(NewBubblesTimer(&selector.todos[0], selector, 0), 5*time.Minute)
+	if m = press(m, "h"); isTimer(m) {
+		t.Fatalf("the timer stayed: %v", m.(TimerModel).err)
+	}
+	waitForHook(t, dir, EventStopped)
+
+	// The switch is reported once the next todo starts
+	if _, err := os.Stat(filepath.Join(dir, EventSwitched)); err == nil {
+		t.Fatal("switched ran before the next todo was picked")
+	}
+	parent := m.(TodoSelectorModel)
+	next := NewBubblesTimer(&parent.todos[1], parent, 1)
+	if next.parentModel.switchedFrom != nil {
+		t.Error("the switch would be reported again")
+	}
+	env, event := waitForHook(t, dir, EventSwitched)
+	if !strings.Contains(env, "COVE_TODO=Review PR\n") {
+		t.Errorf("switched doesn't describe the new session:\n%s", env)
+	}
+	if event.Session == nil || event.Session.Description != "Write report" || event.Session.Duration.Truncate(time.Minute) != 5*time.Minute {
+		t.Errorf("switched doesn't have the recorded session: %+v", event.Session)
+	}
+}
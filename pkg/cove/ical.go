@@ -0,0 +1,163 @@
+package cove
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const icalTimeFormat = "20060102T150405Z"
+
+var isoDurationRegex = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ExportICalendar writes sessions as VEVENTs and open todos as VTODOs so
+// focus time can be imported into a calendar
+func ExportICalendar(w io.Writer, filename string, todos []Todo, sessions []Session) error {
+	cal := &icalWriter{w: w}
+	stamp := time.Now().UTC().Format(icalTimeFormat)
+
+	cal.line("BEGIN:VCALENDAR")
+	cal.line("VERSION:2.0")
+	cal.line("PRODID:-//cove//cove//EN")
+	cal.line("CALSCALE:GREGORIAN")
+
+	for _, session := range sessions {
+		cal.line("BEGIN:VEVENT")
+		cal.line("UID:" + icalUID("session", session.File, session.Description, session.Start.UTC().Format(icalTimeFormat)))
+		cal.line("DTSTAMP:" + stamp)
+		cal.line("DTSTART:" + session.Start.UTC().Format(icalTimeFormat))
+		cal.line("DTEND:" + session.End.UTC().Format(icalTimeFormat))
+		cal.line("SUMMARY:" + icalEscape(session.Description))
+		description := fmt.Sprintf("Focused for %v", session.Duration.Round(time.Minute))
+		if session.Completed {
+			description += ", marked done"
+		}
+		cal.line("DESCRIPTION:" + icalEscape(description))
+		cal.line("CATEGORIES:cove")
+		cal.line("END:VEVENT")
+	}
+
+	for _, todo := range todos {
+		if todo.State == Done {
+			continue
+		}
+		cal.line("BEGIN:VTODO")
+		// The line keeps todos with the same description apart
+		cal.line("UID:" + icalUID("todo", absPath(filename), strconv.Itoa(todo.LineNumber), todo.Description))
+		cal.line("DTSTAMP:" + stamp)
+		cal.line("SUMMARY:" + icalEscape(todo.Description))
+		cal.line("STATUS:" + icalTodoStatus(todo))
+		if !todo.Due.IsZero() {
+			cal.line("DUE;VALUE=DATE:" + todo.Due.Format("20060102"))
+		}
+		// ESTIMATED-DURATION comes from the iCalendar tasks extension draft;
+		// clients that do not know it simply ignore it
+		cal.line("ESTIMATED-DURATION:" + isoDuration(todo.EstimatedTime))
+		cal.line("DESCRIPTION:" + icalEscape(fmt.Sprintf("Estimate %v, spent %v", todo.EstimatedTime, todo.TimeSpent.Round(time.Minute))))
+		if len(todo.Tags) > 0 {
+			tags := make([]string, len(todo.Tags))
+			for i, tag := range todo.Tags {
+				tags[i] = icalEscape(tag)
+			}
+			cal.line("CATEGORIES:" + strings.Join(tags, ","))
+		}
+		cal.line("END:VTODO")
+	}
+
+	cal.line("END:VCALENDAR")
+	return cal.err
+}
+
+// icalWriter writes CRLF-terminated content lines folded at 75 octets,
+// remembering the first error so callers only check once
+type icalWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (c *icalWriter) line(content string) {
+	if c.err != nil {
+		return
+	}
+	var folded strings.Builder
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > 75 {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+	folded.WriteString("\r\n")
+	_, c.err = io.WriteString(c.w, folded.String())
+}
+
+func icalEscape(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r", "", "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+func icalUID(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return fmt.Sprintf("%x@cove", sum)
+}
+
+func icalTodoStatus(todo Todo) string {
+	if todo.TimeSpent > 0 {
+		return "IN-PROCESS"
+	}
+	return "NEEDS-ACTION"
+}
+
+// isoDuration formats a duration as an ISO 8601 / RFC 5545 value like PT1H25M
+func isoDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d <= 0 {
+		return "PT0S"
+	}
+	hours := int(d / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := int(d % time.Minute / time.Second)
+
+	var s strings.Builder
+	s.WriteString("PT")
+	if hours > 0 {
+		fmt.Fprintf(&s, "%dH", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(&s, "%dM", minutes)
+	}
+	if seconds > 0 {
+		fmt.Fprintf(&s, "%dS", seconds)
+	}
+	return s.String()
+}
+
+// parseISODuration reads the day and time parts of an ISO 8601 duration
+// such as P1DT2H30M; years, months and weeks are not supported
+func parseISODuration(value string) (time.Duration, error) {
+	match := isoDurationRegex.FindStringSubmatch(value)
+	if match == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		total += time.Duration(n) * unit
+	}
+	return total, nil
+}
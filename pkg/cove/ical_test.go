@@ -0,0 +1,158 @@
+package cove
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "PT25M", want: 25 * time.Minute},
+		{value: "PT1H30M", want: 90 * time.Minute},
+		{value: "P1DT2H", want: 26 * time.Hour},
+		{value: "PT45S", want: 45 * time.Second},
+		{value: "P2D", want: 48 * time.Hour},
+		{value: "PT0S", want: 0},
+		{value: "", wantErr: true},
+		{value: "P", wantErr: true},
+		{value: "PT", wantErr: true},
+		{value: "P1W", wantErr: true},
+		{value: "25m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseISODuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestISODuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "PT0S"},
+		{d: -time.Minute, want: "PT0S"},
+		{d: 300 * time.Millisecond, want: "PT0S"},
+		{d: 1500 * time.Millisecond, want: "PT2S"},
+		{d: 25 * time.Minute, want: "PT25M"},
+		{d: 90*time.Minute + 5*time.Second, want: "PT1H30M5S"},
+		{d: 26 * time.Hour, want: "PT26H"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := isoDuration(tt.d)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if back, err := parseISODuration(got); err != nil || back != max(tt.d.Round(time.Second), 0) {
+				t.Errorf("parsing %q back gave %v, %v", got, back, err)
+			}
+		})
+	}
+}
+
+func TestICalEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "plain", want: "plain"},
+		{text: `a\b`, want: `a\\b`},
+		{text: "one; two, three", want: `one\; two\, three`},
+		{text: "line\nbreak", want: `line\nbreak`},
+		{text: "crlf\r\nbreak", want: `crlf\nbreak`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := icalEscape(tt.text); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportICalendar(t *testing.T) {
+	todos := []Todo{
+		{Description: "Call the bank", LineNumber: 3, EstimatedTime: 10 * time.Minute},
+		{Description: "Call the bank", LineNumber: 9, EstimatedTime: 10 * time.Minute},
+		{Description: "Done already", LineNumber: 4, State: Done},
+		{
+			Description:   "Write a description long enough that its content line has to be folded #writing",
+			LineNumber:    5,
+			EstimatedTime: 25 * time.Minute,
+			TimeSpent:     5 * time.Minute,
+			Tags:          []string{"writing"},
+			Due:           time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+		},
+	}
+	start := time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC)
+	sessions := []Session{{
+		Description: "Call the bank",
+		File:        "/tmp/todos.md",
+		Start:       start,
+		End:         start.Add(10 * time.Minute),
+		Duration:    10 * time.Minute,
+		Completed:   true,
+	}}
+
+	var out strings.Builder
+	if err := ExportICalendar(&out, "/tmp/todos.md", todos, sessions); err != nil {
+		t.Fatal(err)
+	}
+	cal := out.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(cal, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("content line longer than 75 octets: %q", line)
+		}
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("bare line break in %q", line)
+		}
+	}
+	if got := strings.Count(cal, "BEGIN:VTODO"); got != 3 {
+		t.Errorf("got %d VTODOs, want 3 without the done todo", got)
+	}
+	if got := strings.Count(cal, "BEGIN:VEVENT"); got != 1 {
+		t.Errorf("got %d VEVENTs, want 1", got)
+	}
+
+	uids := map[string]bool{}
+	for _, line := range strings.Split(cal, "\r\n") {
+		if strings.HasPrefix(line, "UID:") {
+			if uids[line] {
+				t.Errorf("duplicate %s", line)
+			}
+			uids[line] = true
+		}
+	}
+
+	unfolded := strings.ReplaceAll(cal, "\r\n ", "")
+	for _, want := range []string{
+		"DTSTART:20240430T090000Z",
+		"DTEND:20240430T091000Z",
+		`DESCRIPTION:Focused for 10m0s\, marked done`,
+		"STATUS:IN-PROCESS",
+		"DUE;VALUE=DATE:20240501",
+		"ESTIMATED-DURATION:PT25M",
+		"CATEGORIES:writing",
+	} {
+		if !strings.Contains(unfolded, want+"\r\n") {
+			t.Errorf("missing %q in:\n%s", want, cal)
+		}
+	}
+}
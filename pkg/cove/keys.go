@@ -0,0 +1,182 @@
+package cove
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// KeyMap holds the TUI's key bindings. The help view is generated from it,
+// so remapped keys show up there too.
+type KeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Start  key.Binding
+	Quit   key.Binding
+	Pause  key.Binding
+	Switch key.Binding
+	Done   key.Binding
+	Yes    key.Binding
+	No     key.Binding
+	Skip   key.Binding
+	Help   key.Binding
+	// Resume, Commit and Discard answer the prompt about a session left
+	// behind when the TUI didn't exit cleanly
+	Resume  key.Binding
+	Commit  key.Binding
+	Discard key.Binding
+	// Stopwatch starts the selected todo counting up instead of down
+	Stopwatch key.Binding
+	// Search fuzzy-searches the selector and Filter cycles through the
+	// saved filters
+	Search key.Binding
+	Filter key.Binding
+}
+
+// NewKeyMap builds the bindings from the [keys] table of the config, which
+// maps each action to the keys that trigger it
+func NewKeyMap(keys map[string][]string) KeyMap {
+	return KeyMap{
+		Up:     newBinding(keys["up"], "up"),
+		Down:   newBinding(keys["down"], "down"),
+		Start:  newBinding(keys["start"], "start timer"),
+		Quit:   newBinding(keys["quit"], "quit"),
+		Pause:  newBinding(keys["pause"], "pause/resume"),
+		Switch: newBinding(keys["switch"], "switch task"),
+		Done:   newBinding(keys["done"], "mark done"),
+		Yes:    newBinding(keys["yes"], "keep going"),
+		No:     newBinding(keys["no"], "back to list"),
+		Skip:   newBinding(keys["skip"], "skip break"),
+		Help:   newBinding(keys["help"], "toggle help"),
+
+		Resume:  newBinding(keys["resume"], "resume session"),
+		Commit:  newBinding(keys["commit"], "record its time"),
+		Discard: newBinding(keys["discard"], "discard it"),
+
+		Stopwatch: newBinding(keys["stopwatch"], "start stopwatch"),
+		Search:    newBinding(keys["search"], "search"),
+		Filter:    newBinding(keys["filter"], "next filter"),
+	}
+}
+
+// newBinding binds keys as named by tea.KeyMsg.String(), with "space" also
+// naming the space bar
+func newBinding(keys []string, description string) key.Binding {
+	bound := make([]string, 0, len(keys))
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		name := k
+		switch k {
+		case "space":
+			k = " "
+		case "up":
+			name = "↑"
+		case "down":
+			name = "↓"
+		}
+		bound = append(bound, k)
+		names = append(names, name)
+	}
+	return key.NewBinding(
+		key.WithKeys(bound...),
+		key.WithHelp(strings.Join(names, "/"), description),
+	)
+}
+
+// keyHelp lists the bindings that apply to one screen for help.Model
+type keyHelp struct {
+	short []key.Binding
+	full  [][]key.Binding
+}
+
+func (h keyHelp) ShortHelp() []key.Binding  { return h.short }
+func (h keyHelp) FullHelp() [][]key.Binding { return h.full }
+
+// selectorHelp takes the list's binding for clearing a search, which is
+// only enabled while one is applied
+func (k KeyMap) selectorHelp(clearSearch key.Binding) keyHelp {
+	return keyHelp{
+		short: []key.Binding{k.Up, k.Down, k.Start, k.Search, clearSearch, k.Quit, k.Help},
+		full: [][]key.Binding{
+			{k.Up, k.Down},
+			{k.Start, k.Stopwatch},
+			{k.Search, clearSearch, k.Filter},
+			{k.Help, k.Quit},
+		},
+	}
+}
+
+// searchHelp is shown while typing a search, using the list's own bindings
+func searchHelp(keys list.KeyMap) keyHelp {
+	return keyHelp{
+		short: []key.Binding{keys.AcceptWhileFiltering, keys.CancelWhileFiltering},
+		full:  [][]key.Binding{{keys.AcceptWhileFiltering, keys.CancelWhileFiltering}},
+	}
+}
+
+func (k KeyMap) recoveryHelp() keyHelp {
+	return keyHelp{
+		short: []key.Binding{k.Resume, k.Commit, k.Discard, k.Quit},
+		full: [][]key.Binding{
+			{k.Resume, k.Commit, k.Discard},
+			{k.Help, k.Quit},
+		},
+	}
+}
+
+func (k KeyMap) quitHelp() keyHelp {
+	yes, no := k.Yes, k.No
+	yes.SetHelp(yes.Help().Key, "record and quit")
+	no.SetHelp(no.Help().Key, "keep working")
+	return keyHelp{
+		short: []key.Binding{yes, no},
+		full:  [][]key.Binding{{yes, no}},
+	}
+}
+
+func (k KeyMap) timerHelp() keyHelp {
+	return keyHelp{
+		short: []key.Binding{k.Pause, k.Switch, k.Done, k.Quit, k.Help},
+		full: [][]key.Binding{
+			{k.Pause, k.Switch, k.Done},
+			{k.Help, k.Quit},
+		},
+	}
+}
+
+func (k KeyMap) breakHelp() keyHelp {
+	return keyHelp{
+		short: []key.Binding{k.Pause, k.Skip, k.Switch, k.Quit, k.Help},
+		full: [][]key.Binding{
+			{k.Pause, k.Skip},
+			{k.Switch, k.Done},
+			{k.Help, k.Quit},
+		},
+	}
+}
+
+func (k KeyMap) overtimeHelp() keyHelp {
+	yes, no := k.Yes, k.No
+	yes.SetHelp(yes.Help().Key, "new interval")
+	no.SetHelp(no.Help().Key, "stop")
+	return keyHelp{
+		short: []key.Binding{k.Pause, yes, no, k.Done, k.Quit, k.Help},
+		full: [][]key.Binding{
+			{k.Pause, yes, no},
+			{k.Switch, k.Done},
+			{k.Help, k.Quit},
+		},
+	}
+}
+
+func (k KeyMap) timeoutHelp() keyHelp {
+	return keyHelp{
+		short: []key.Binding{k.Yes, k.No, k.Done, k.Quit, k.Help},
+		full: [][]key.Binding{
+			{k.Yes, k.No},
+			{k.Done, k.Switch},
+			{k.Help, k.Quit},
+		},
+	}
+}
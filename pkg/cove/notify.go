@@ -0,0 +1,114 @@
+package cove
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// NotifyConfig is the [notify] table, saying how to get the user's
+// attention when a work interval or break runs out
+type NotifyConfig struct {
+	// WorkEnd and BreakEnd pick which timeouts notify
+	WorkEnd  bool `toml:"work_end"`
+	BreakEnd bool `toml:"break_end"`
+	// Bell rings the terminal bell
+	Bell bool `toml:"bell"`
+	// OSC sends a desktop notification through the terminal: "9" for
+	// iTerm2, Windows Terminal and others, "777" for urxvt, foot and
+	// Ghostty, or "" for none
+	OSC string `toml:"osc"`
+	// Desktop runs notify-send
+	Desktop bool `toml:"desktop"`
+	// Command is run by the shell with COVE_NOTIFY_TITLE and
+	// COVE_NOTIFY_BODY set
+	Command string `toml:"command"`
+}
+
+var oscModes = []string{"", "9", "777"}
+
+// notifyFailedMsg reports notifications that could not be sent
+type notifyFailedMsg struct {
+	err error
+}
+
+// notify returns a command sending every configured notification. Escape
+// sequences are written to terminal, which must be the program's output so
+// they don't land in the middle of a frame.
+func (n NotifyConfig) notify(terminal io.Writer, title, body string) tea.Cmd {
+	return func() tea.Msg {
+		var errs []error
+		var out strings.Builder
+		if n.Bell {
+			out.WriteString("\a")
+		}
+		switch n.OSC {
+		case "9":
+			fmt.Fprintf(&out, "\x1b]9;%s: %s\x07", oscText(title), oscText(body))
+		case "777":
+			fmt.Fprintf(&out, "\x1b]777;notify;%s;%s\x07", oscText(title), oscText(body))
+		}
+		if out.Len() > 0 {
+			if _, err := io.WriteString(terminal, out.String()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if n.Desktop {
+			if err := exec.Command("notify-send", "--app-name=cove", title, body).Run(); err != nil {
+				errs = append(errs, fmt.Errorf("notify-send: %w", err))
+			}
+		}
+		if n.Command != "" {
+			cmd := exec.Command("sh", "-c", n.Command)
+			cmd.Env = append(os.Environ(), "COVE_NOTIFY_TITLE="+title, "COVE_NOTIFY_BODY="+body)
+			if err := cmd.Run(); err != nil {
+				errs = append(errs, fmt.Errorf("notify command: %w", err))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return notifyFailedMsg{err: err}
+		}
+		return nil
+	}
+}
+
+// Terminal is the TUI's output with its writes serialized, so that
+// notifications sent from a command can't interleave with a frame the
+// renderer is writing. Give it to both tea.WithOutput and
+// TodoSelectorModel.WithTerminal.
+type Terminal struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewTerminal(file *os.File) *Terminal {
+	return &Terminal{file: file}
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file.Write(p)
+}
+
+// Read, Close and Fd let Bubble Tea size and set up the terminal it wraps
+func (t *Terminal) Read(p []byte) (int, error) { return t.file.Read(p) }
+func (t *Terminal) Close() error               { return t.file.Close() }
+func (t *Terminal) Fd() uintptr                { return t.file.Fd() }
+
+// oscText drops characters that would end or split an OSC sequence
+func oscText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == ';' {
+			return -1
+		}
+		return r
+	}, text)
+}
@@ -1,6 +1,8 @@
 package cove
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -135,4 +137,64 @@ func similarDescriptions(desc1, desc2 string) bool {
 	}
 	
 	return false
+}
+
+// MatchTodo finds the todo that was at line with the given description,
+// following it if the file has since been edited. It returns -1 if the
+// todo can no longer be found.
+func MatchTodo(todos []Todo, line int, description string) int {
+	// Still on the same line
+	for i, todo := range todos {
+		if todo.LineNumber == line && similarDescriptions(todo.Description, description) {
+			return i
+		}
+	}
+
+	// Moved elsewhere in the file
+	for i, todo := range todos {
+		if similarDescriptions(todo.Description, description) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// FindTodo picks a todo by line number or by a case-insensitive pattern in
+// its description. When a pattern matches several todos, open ones win.
+func FindTodo(todos []Todo, selector string) (int, error) {
+	if line, err := strconv.Atoi(selector); err == nil {
+		for i, todo := range todos {
+			if todo.LineNumber == line {
+				return i, nil
+			}
+		}
+		return -1, newEngineError(ErrTodoNotFound, "no todo on line %d", line)
+	}
+
+	pattern := strings.ToLower(selector)
+	var matches, open []int
+	for i, todo := range todos {
+		if strings.Contains(strings.ToLower(todo.Description), pattern) {
+			matches = append(matches, i)
+			if todo.State == Open {
+				open = append(open, i)
+			}
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return -1, newEngineError(ErrTodoNotFound, "no todo matches %q", selector)
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(open) == 1:
+		return open[0], nil
+	}
+
+	var candidates []string
+	for _, i := range matches {
+		candidates = append(candidates, fmt.Sprintf("  %d: %s", todos[i].LineNumber, todos[i].Description))
+	}
+	return -1, newEngineError(ErrAmbiguousTodo, "%q matches %d todos, use a line number:\n%s", selector, len(matches), strings.Join(candidates, "\n"))
 }
\ No newline at end of file
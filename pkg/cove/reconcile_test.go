@@ -0,0 +1,70 @@
+package cove
+
+import (
+	"errors"
+	"testing"
+)
+
+func testTodos() []Todo {
+	return []Todo{
+		{Description: "Write report", LineNumber: 3},
+		{Description: "Review PR #42", LineNumber: 4, State: Done},
+		{Description: "Review docs", LineNumber: 5},
+		{Description: "Deploy", LineNumber: 7, State: Done},
+		{Description: "Deploy staging", LineNumber: 8, State: Done},
+	}
+}
+
+func TestFindTodo(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     int
+		err      error
+	}{
+		{selector: "3", want: 0},
+		{selector: "8", want: 4},
+		{selector: "6", want: -1, err: ErrTodoNotFound},
+		{selector: "write", want: 0},
+		{selector: "REPORT", want: 0},
+		// Open todos win over done ones
+		{selector: "review", want: 2},
+		{selector: "deploy", want: -1, err: ErrAmbiguousTodo},
+		{selector: "lunch", want: -1, err: ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := FindTodo(testTodos(), tt.selector)
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMatchTodo(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        int
+		description string
+		want        int
+	}{
+		{name: "same line", line: 5, description: "Review docs", want: 2},
+		{name: "moved", line: 10, description: "Write report", want: 0},
+		{name: "case changed", line: 3, description: "write REPORT", want: 0},
+		{name: "small edit", line: 5, description: "Review doc", want: 2},
+		{name: "too short to be the same", line: 8, description: "Deploy", want: 3},
+		{name: "gone", line: 3, description: "Book flights", want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchTodo(testTodos(), tt.line, tt.description); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package cove
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/timer"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// checkpointInterval is how often a running session is saved to the state
+// file, and so the most time a crash can lose
+const checkpointInterval = 10 * time.Second
+
+// updateRecovery handles the prompt about a session the TUI was timing when
+// it last went away: resume it, record its time, or throw it away
+func (m TodoSelectorModel) updateRecovery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	recovered := m.recovered.Recovered()
+	index := MatchTodo(m.todos, recovered.Line, recovered.Description)
+
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+	case key.Matches(msg, m.keys.Resume):
+		if index < 0 {
+			return m, nil
+		}
+		m.recovered = nil
+		t := m.recoveredTimer(recovered, index)
+		t.session.Resume()
+		t = t.saveState()
+		t.runHook(EventResumed, nil)
+		if t.session.Estimate > 0 && t.session.Remaining() <= 0 {
+			// A countdown with nothing left never times out on its own
+			return t.Update(timer.TimeoutMsg{ID: t.timer.ID()})
+		}
+		return t, t.Init()
+	case key.Matches(msg, m.keys.Commit):
+		if index < 0 {
+			return m, nil
+		}
+		saved, _, err := m.recoveredTimer(recovered, index).saveWork(false)
+		if err != nil {
+			// The prompt stays up, so the session can be recorded later
+			m.err = err
+			return m, nil
+		}
+		m = saved.parentModel
+		m.recovered = nil
+		m.todos = sortTodos(m.todos)
+		m = m.setItems()
+	case key.Matches(msg, m.keys.Discard):
+		if err := ClearActiveSession(m.statePath); err != nil {
+			m.err = fmt.Errorf("failed to discard the session: %w", err)
+			return m, nil
+		}
+		m.recovered = nil
+		event := Event{Type: EventDiscarded, Time: time.Now(), Active: &recovered}
+		m.hooks.Run(event)
+		m.bus.publishSession(event)
+	}
+	return m, nil
+}
+
+// recoveredTimer rebuilds the timer of a recovered session, still paused
+func (m TodoSelectorModel) recoveredTimer(recovered ActiveSession, index int) TimerModel {
+	recovered.File = absPath(m.filename)
+	return newLocalTimer(&m.todos[index], m, index, recovered)
+}
+
+func (m TodoSelectorModel) recoveryView() string {
+	var s strings.Builder
+	recovered := m.recovered.Recovered()
+
+	titleStyle := m.theme.Badge(m.theme.Accent).
+		Padding(0, 1)
+	textStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Text)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Muted)
+
+	s.WriteString(titleStyle.Render("⏱️ Unfinished session"))
+	s.WriteString("\n\n")
+	s.WriteString(textStyle.Render(fmt.Sprintf("Cove was closed while timing %q.", recovered.Description)))
+	s.WriteString("\n")
+	s.WriteString(mutedStyle.Render(fmt.Sprintf("Worked %v since %s.",
+		recovered.Elapsed().Round(time.Second), recovered.Started.Format("Mon 15:04"))))
+	s.WriteString("\n\n")
+	s.WriteString(m.errorView())
+
+	keys := m.keys
+	if MatchTodo(m.todos, recovered.Line, recovered.Description) < 0 {
+		s.WriteString(mutedStyle.Render("The todo is no longer in the file, so the session can only be discarded."))
+		s.WriteString("\n\n")
+		keys.Resume.SetEnabled(false)
+		keys.Commit.SetEnabled(false)
+	}
+	s.WriteString(m.help.View(keys.recoveryHelp()))
+
+	return s.String()
+}
@@ -0,0 +1,365 @@
+package cove
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The web UI mirrors the TUI selector and timer using the API below
+//
+//go:embed web
+var webFiles embed.FS
+
+// Server exposes the todos of one markdown file and control of the timer
+// as a JSON API with a server-sent event stream of changes
+type Server struct {
+	controller Controller
+	filename   string
+	// addr is the address the server listens on; requests must name it or
+	// a loopback host
+	addr      string
+	estimates Estimates
+
+	mu       sync.Mutex
+	fileSubs map[chan struct{}]struct{}
+}
+
+func NewServer(controller Controller, filename, addr string) *Server {
+	return &Server{
+		controller: controller,
+		filename:   filename,
+		addr:       addr,
+		estimates:  DefaultEstimates(),
+		fileSubs:   make(map[chan struct{}]struct{}),
+	}
+}
+
+// WithEstimates reads the star hints of todos with the given estimates
+func (s *Server) WithEstimates(estimates Estimates) *Server {
+	s.estimates = estimates
+	return s
+}
+
+// ServerEvent is the data of each server-sent event
+type ServerEvent struct {
+	Type    string         `json:"type"`
+	Time    time.Time      `json:"time"`
+	Status  *ActiveRecord  `json:"status"`
+	Session *SessionRecord `json:"session,omitempty"`
+}
+
+// apiRequest is the optional JSON body of POST requests
+type apiRequest struct {
+	Todo string `json:"todo"`
+	Done bool   `json:"done"`
+}
+
+// Handler returns the web UI and API routes. Watch must be running for the
+// event stream to report file changes.
+func (s *Server) Handler() http.Handler {
+	web, _ := fs.Sub(webFiles, "web")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServer(http.FS(web)))
+	mux.HandleFunc("GET /api/todos", s.handleTodos)
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("POST /api/start", s.handleStart)
+	mux.HandleFunc("POST /api/switch", s.handleSwitch)
+	mux.HandleFunc("POST /api/pause", s.handlePause)
+	mux.HandleFunc("POST /api/resume", s.handleResume)
+	mux.HandleFunc("POST /api/stop", s.handleStop)
+	mux.HandleFunc("POST /api/discard", s.handleDiscard)
+	mux.HandleFunc("POST /api/done", s.handleDone)
+	return s.guard(mux)
+}
+
+// guard turns away requests that a web page on another site could make.
+// Checking Host defeats DNS rebinding, which would otherwise let the page
+// read the API. Checking Origin and requiring JSON bodies keeps cross-site
+// forms, which browsers send without asking, from driving the timer.
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			writeAPIJSON(w, http.StatusForbidden, map[string]string{"error": fmt.Sprintf("host %q is not allowed", r.Host), "code": "forbidden"})
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				writeAPIJSON(w, http.StatusForbidden, map[string]string{"error": fmt.Sprintf("origin %q is not allowed", origin), "code": "forbidden"})
+				return
+			}
+		}
+		if r.Method == http.MethodPost {
+			if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+				writeAPIJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "requests must be sent as application/json", "code": "bad_request"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether a request's Host header names this server:
+// a loopback host, or the host it listens on. Listening on all interfaces
+// also allows any IP address, since those can't be rebound.
+func (s *Server) allowedHost(host string) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.Trim(host, "[]")
+	if host == "" {
+		return false
+	}
+	if IsLoopbackHost(host) {
+		return true
+	}
+
+	listenHost, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, listenHost) {
+		return true
+	}
+	if ip := net.ParseIP(listenHost); listenHost == "" || ip != nil && ip.IsUnspecified() {
+		return net.ParseIP(host) != nil
+	}
+	return false
+}
+
+// IsLoopbackHost reports whether host is localhost or a loopback address
+func IsLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Watch reports changes to the markdown file to event stream clients until
+// the returned watcher is closed
+func (s *Server) Watch() (*FileWatcher, error) {
+	return NewFileWatcher(s.filename, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for ch := range s.fileSubs {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	})
+}
+
+func (s *Server) handleTodos(w http.ResponseWriter, r *http.Request) {
+	todos, err := ReadTodosWithEstimates(s.filename, s.estimates)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	records := make([]TodoRecord, 0, len(todos))
+	for _, todo := range todos {
+		records = append(records, NewTodoRecord(todo, s.filename))
+	}
+	writeAPIJSON(w, http.StatusOK, records)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	active, err := s.controller.Status()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, activeRecord(active))
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	req, ok := readAPIRequest(w, r)
+	if !ok {
+		return
+	}
+	s.respondActive(w)(s.controller.Start(s.filename, req.Todo))
+}
+
+func (s *Server) handleSwitch(w http.ResponseWriter, r *http.Request) {
+	req, ok := readAPIRequest(w, r)
+	if !ok {
+		return
+	}
+	s.respondActive(w)(s.controller.Switch(s.filename, req.Todo))
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	s.respondActive(w)(s.controller.Pause())
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.respondActive(w)(s.controller.Resume())
+}
+
+func (s *Server) handleDiscard(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.controller.Discard(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	req, ok := readAPIRequest(w, r)
+	if !ok {
+		return
+	}
+	session, err := s.controller.Stop(req.Done)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, NewSessionRecord(session))
+}
+
+func (s *Server) handleDone(w http.ResponseWriter, r *http.Request) {
+	req, ok := readAPIRequest(w, r)
+	if !ok {
+		return
+	}
+	todo, err := s.controller.Done(s.filename, req.Todo)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, NewTodoRecord(todo, s.filename))
+}
+
+// handleEvents streams timer events and "todos" events when the markdown
+// file changes, starting with the current status
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, errors.New("streaming is not supported"))
+		return
+	}
+
+	events, cancel, err := s.controller.Subscribe()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	defer cancel()
+
+	fileChanged := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.fileSubs[fileChanged] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.fileSubs, fileChanged)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	active, _ := s.controller.Status()
+	writeServerEvent(w, ServerEvent{Type: "status", Time: time.Now(), Status: activeRecord(active)})
+	flusher.Flush()
+
+	// Comments keep proxies and browsers from timing out an idle stream
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			serverEvent := ServerEvent{Type: event.Type, Time: event.Time, Status: activeRecord(event.Active)}
+			if event.Session != nil {
+				record := NewSessionRecord(*event.Session)
+				serverEvent.Session = &record
+			}
+			writeServerEvent(w, serverEvent)
+		case <-fileChanged:
+			active, _ := s.controller.Status()
+			writeServerEvent(w, ServerEvent{Type: "todos", Time: time.Now(), Status: activeRecord(active)})
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// respondActive writes the session returned by one of the controller calls
+func (s *Server) respondActive(w http.ResponseWriter) func(*ActiveSession, error) {
+	return func(active *ActiveSession, err error) {
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, activeRecord(active))
+	}
+}
+
+func activeRecord(active *ActiveSession) *ActiveRecord {
+	if active == nil {
+		return nil
+	}
+	record := NewActiveRecord(active)
+	return &record
+}
+
+func readAPIRequest(w http.ResponseWriter, r *http.Request) (apiRequest, bool) {
+	var req apiRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeAPIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body: " + err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	code := errorCode(err)
+	status := http.StatusInternalServerError
+	switch code {
+	case "not_found":
+		status = http.StatusNotFound
+	case "not_running", "already_running", "ambiguous", "done":
+		status = http.StatusConflict
+	}
+	writeAPIJSON(w, status, map[string]string{"error": err.Error(), "code": code})
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("API write error: %v", err)
+	}
+}
+
+func writeServerEvent(w io.Writer, event ServerEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
@@ -0,0 +1,113 @@
+package cove
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerGuard(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		host        string
+		origin      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{name: "read todos", method: "GET", path: "/api/todos", host: "127.0.0.1:7878", want: http.StatusOK},
+		{name: "read from localhost", method: "GET", path: "/api/status", host: "localhost:7878", want: http.StatusOK},
+		{name: "read from IPv6 loopback", method: "GET", path: "/api/status", host: "[::1]:7878", want: http.StatusOK},
+		{name: "web UI", method: "GET", path: "/", host: "127.0.0.1:7878", want: http.StatusOK},
+		{name: "rebound host", method: "GET", path: "/api/todos", host: "evil.example:7878", want: http.StatusForbidden},
+		{name: "no host", method: "GET", path: "/api/todos", host: "", want: http.StatusForbidden},
+		{
+			name: "start", method: "POST", path: "/api/start", host: "127.0.0.1:7878",
+			contentType: "application/json", body: `{"todo":"report"}`, want: http.StatusOK,
+		},
+		{
+			name: "same origin", method: "POST", path: "/api/start", host: "127.0.0.1:7878", origin: "http://127.0.0.1:7878",
+			contentType: "application/json; charset=utf-8", body: `{"todo":"report"}`, want: http.StatusOK,
+		},
+		{
+			name: "cross-site origin", method: "POST", path: "/api/start", host: "127.0.0.1:7878", origin: "https://evil.example",
+			contentType: "application/json", body: `{"todo":"report"}`, want: http.StatusForbidden,
+		},
+		{
+			name: "cross-site read", method: "GET", path: "/api/todos", host: "127.0.0.1:7878", origin: "https://evil.example",
+			want: http.StatusForbidden,
+		},
+		{
+			name: "form post", method: "POST", path: "/api/stop", host: "127.0.0.1:7878",
+			contentType: "application/x-www-form-urlencoded", body: "done=true", want: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "plain text post", method: "POST", path: "/api/stop", host: "127.0.0.1:7878",
+			contentType: "text/plain", body: `{"done":true}`, want: http.StatusUnsupportedMediaType,
+		},
+		{name: "empty post", method: "POST", path: "/api/stop", host: "127.0.0.1:7878", want: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, path := newTestEngine(t)
+			handler := NewServer(engine, path, "127.0.0.1:7878").Handler()
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("got %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			// Turned away requests must not reach the timer
+			if active, _ := engine.Status(); active != nil && tt.want != http.StatusOK {
+				t.Errorf("a rejected request started %q", active.Description)
+			}
+		})
+	}
+}
+
+func TestServerAllowedHost(t *testing.T) {
+	tests := []struct {
+		addr string
+		host string
+		want bool
+	}{
+		{addr: "127.0.0.1:7878", host: "127.0.0.1:7878", want: true},
+		{addr: "127.0.0.1:7878", host: "localhost", want: true},
+		{addr: "127.0.0.1:7878", host: "LOCALHOST:7878", want: true},
+		{addr: "127.0.0.1:7878", host: "[::1]:7878", want: true},
+		{addr: "127.0.0.1:7878", host: "192.168.1.5:7878", want: false},
+		{addr: "127.0.0.1:7878", host: "attacker.example", want: false},
+		{addr: "127.0.0.1:7878", host: "localhost.attacker.example", want: false},
+		{addr: "192.168.1.5:7878", host: "192.168.1.5:7878", want: true},
+		{addr: "192.168.1.5:7878", host: "10.0.0.1:7878", want: false},
+		{addr: "laptop.lan:7878", host: "laptop.lan:7878", want: true},
+		{addr: "laptop.lan:7878", host: "attacker.example:7878", want: false},
+		// On all interfaces any IP address is fine, but names could be rebound
+		{addr: "0.0.0.0:7878", host: "10.0.0.1:7878", want: true},
+		{addr: ":7878", host: "[fe80::1]:7878", want: true},
+		{addr: ":7878", host: "attacker.example:7878", want: false},
+		{addr: "127.0.0.1:7878", host: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr+" "+tt.host, func(t *testing.T) {
+			server := NewServer(nil, "todos.md", tt.addr)
+			if got := server.allowedHost(tt.host); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
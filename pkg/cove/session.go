@@ -0,0 +1,118 @@
+package cove
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Session is one stretch of work on a todo, recorded when the timer is left
+type Session struct {
+	Description string        `json:"description"`
+	File        string        `json:"file"`
+	Line        int           `json:"line"`
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	Duration    time.Duration `json:"duration"`
+	// Estimate is the estimate the session was timed against; 0 for a
+	// stopwatch
+	Estimate time.Duration `json:"estimate,omitempty"`
+	// Overtime is the part of Duration past the todo's estimate
+	Overtime  time.Duration `json:"overtime,omitempty"`
+	Completed bool          `json:"completed"`
+}
+
+// DefaultHistoryPath returns where sessions are stored, following XDG_DATA_HOME
+func DefaultHistoryPath() string {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(".cove", "history.jsonl")
+		}
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, "cove", "history.jsonl")
+}
+
+// AppendSession adds a session to the history file, one JSON object per line
+func AppendSession(path string, session Session) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	if _, err := fmt.Fprintln(file, string(data)); err != nil {
+		return fmt.Errorf("error writing session: %w", err)
+	}
+
+	return nil
+}
+
+// ReadSessions loads all sessions from the history file.
+// A missing history file is not an error; it just means nothing was recorded yet.
+func ReadSessions(path string) ([]Session, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	var sessions []Session
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal(scanner.Bytes(), &session); err != nil {
+			return nil, fmt.Errorf("invalid session on line %d: %w", lineNumber, err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history file: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// SessionsForFile returns the sessions that were recorded against filename
+func SessionsForFile(sessions []Session, filename string) []Session {
+	target := absPath(filename)
+
+	var result []Session
+	for _, session := range sessions {
+		if absPath(session.File) == target {
+			result = append(result, session)
+		}
+	}
+	return result
+}
+
+func absPath(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		return abs
+	}
+	return filepath.Clean(filename)
+}
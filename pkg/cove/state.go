@@ -0,0 +1,183 @@
+package cove
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// ActiveSession is a timer that is running outside the TUI, persisted so
+// separate `cove start`, `cove pause` and `cove stop` invocations can share it
+type ActiveSession struct {
+	File        string        `json:"file"`
+	Description string        `json:"description"`
+	Line        int           `json:"line"`
+	Estimate    time.Duration `json:"estimate"`
+	Started     time.Time     `json:"started"`
+	// Resumed is when the current running stretch began (Started if never
+	// paused) and Worked is the time accumulated before it
+	Resumed time.Time     `json:"resumed"`
+	Worked  time.Duration `json:"worked,omitempty"`
+	Paused  bool          `json:"paused,omitempty"`
+	// Saved is the part of the time worked that autosaving has already
+	// added to the todo in the markdown file
+	Saved time.Duration `json:"saved,omitempty"`
+	// Checkpoint is when the TUI last saved the session while timing it,
+	// so a session left behind by a crash doesn't count the time since
+	Checkpoint time.Time `json:"checkpoint,omitempty"`
+	// Owner is the process ID of the TUI timing the session. Only once it
+	// has gone away may another TUI recover the session.
+	Owner int `json:"owner,omitempty"`
+}
+
+// Elapsed is the time worked so far, not counting pauses
+func (a *ActiveSession) Elapsed() time.Duration {
+	if a.Paused {
+		return a.Worked
+	}
+	resumed := a.Resumed
+	if resumed.IsZero() {
+		resumed = a.Started
+	}
+	return a.Worked + time.Since(resumed)
+}
+
+// Remaining is the time left until the estimate is used up
+func (a *ActiveSession) Remaining() time.Duration {
+	if remaining := a.Estimate - a.Elapsed(); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Overtime is the time worked past the estimate
+func (a *ActiveSession) Overtime() time.Duration {
+	return overtime(a.Elapsed(), a.Estimate)
+}
+
+// overtime is the part of elapsed past estimate. Sessions without an
+// estimate have none.
+func overtime(elapsed, estimate time.Duration) time.Duration {
+	if estimate <= 0 {
+		return 0
+	}
+	return max(elapsed-estimate, 0)
+}
+
+func (a *ActiveSession) Pause() {
+	if a.Paused {
+		return
+	}
+	a.Worked = a.Elapsed()
+	a.Paused = true
+}
+
+func (a *ActiveSession) Resume() {
+	if !a.Paused {
+		return
+	}
+	a.Resumed = time.Now()
+	a.Paused = false
+}
+
+// Recovered returns the session as it stood at its last checkpoint, paused,
+// for picking it up after the process timing it went away
+func (a ActiveSession) Recovered() ActiveSession {
+	if !a.Paused && !a.Checkpoint.IsZero() {
+		if worked := a.Checkpoint.Sub(a.Resumed); worked > 0 {
+			a.Worked += worked
+		}
+		a.Paused = true
+	}
+	return a
+}
+
+// Orphaned reports whether the TUI that was timing the session is gone, so
+// that it can be recovered without being recorded twice
+func (a ActiveSession) Orphaned() bool {
+	if a.Owner == 0 || a.Owner == os.Getpid() {
+		return true
+	}
+	process, err := os.FindProcess(a.Owner)
+	if err != nil {
+		return true
+	}
+	// Signal 0 only checks that the process exists; EPERM means it does but
+	// belongs to someone else
+	err = process.Signal(syscall.Signal(0))
+	return err != nil && !errors.Is(err, syscall.EPERM)
+}
+
+// DefaultStatePath returns where the active session is kept, following XDG_STATE_HOME
+func DefaultStatePath() string {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(".cove", "active.json")
+		}
+		stateDir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateDir, "cove", "active.json")
+}
+
+// TUIStatePath returns where the TUI keeps the session it is timing in
+// filename, so it can be recovered if the TUI doesn't exit cleanly. Each
+// markdown file gets its own, next to the CLI's state file.
+func TUIStatePath(filename string) string {
+	sum := sha256.Sum256([]byte(absPath(filename)))
+	return filepath.Join(filepath.Dir(DefaultStatePath()), "tui", hex.EncodeToString(sum[:8])+".json")
+}
+
+// LoadActiveSession returns the persisted session, or nil if none is running
+func LoadActiveSession(path string) (*ActiveSession, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var active ActiveSession
+	if err := json.Unmarshal(data, &active); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return &active, nil
+}
+
+// SaveActiveSession writes the session atomically so a crash never leaves
+// a half-written state file behind
+func SaveActiveSession(path string, active *ActiveSession) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(active, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// ClearActiveSession removes the state file; it is fine if there is none
+func ClearActiveSession(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove state file: %w", err)
+	}
+	return nil
+}
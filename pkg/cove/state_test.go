@@ -0,0 +1,104 @@
+package cove
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestActiveSessionRecovered(t *testing.T) {
+	start := time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		session ActiveSession
+		want    time.Duration
+	}{
+		{
+			name:    "running until the last checkpoint",
+			session: ActiveSession{Started: start, Resumed: start, Checkpoint: start.Add(12 * time.Minute)},
+			want:    12 * time.Minute,
+		},
+		{
+			name: "resumed after a pause",
+			session: ActiveSession{
+				Started: start, Resumed: start.Add(time.Hour), Worked: 5 * time.Minute,
+				Checkpoint: start.Add(time.Hour + 3*time.Minute),
+			},
+			want: 8 * time.Minute,
+		},
+		{
+			name:    "paused",
+			session: ActiveSession{Started: start, Resumed: start, Worked: 5 * time.Minute, Paused: true, Checkpoint: start.Add(time.Hour)},
+			want:    5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recovered := tt.session.Recovered()
+			if !recovered.Paused {
+				t.Error("a recovered session should wait paused")
+			}
+			// The time since the last checkpoint can't be known, so it isn't counted
+			if elapsed := recovered.Elapsed(); elapsed != tt.want {
+				t.Errorf("got %v worked, want %v", elapsed, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveSessionOrphaned(t *testing.T) {
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skip("can't run a process to outlive:", err)
+	}
+
+	tests := []struct {
+		name  string
+		owner int
+		want  bool
+	}{
+		{name: "no owner", owner: 0, want: true},
+		{name: "this process", owner: os.Getpid(), want: true},
+		{name: "another running process", owner: os.Getppid(), want: false},
+		{name: "a process that exited", owner: exited.Process.Pid, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := ActiveSession{Owner: tt.owner}
+			if got := session.Orphaned(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveSessionState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "active.json")
+	if active, err := LoadActiveSession(path); err != nil || active != nil {
+		t.Fatalf("got %+v, %v without a state file", active, err)
+	}
+
+	start := time.Now().Add(-time.Hour).Round(0)
+	session := &ActiveSession{File: "/todos.md", Description: "Write report", Line: 2, Estimate: 10 * time.Minute, Started: start, Resumed: start}
+	if err := SaveActiveSession(path, session); err != nil {
+		t.Fatal(err)
+	}
+	active, err := LoadActiveSession(path)
+	if err != nil || active == nil || active.Description != session.Description || !active.Started.Equal(start) {
+		t.Fatalf("got %+v, %v", active, err)
+	}
+
+	if err := ClearActiveSession(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClearActiveSession(path); err != nil {
+		t.Errorf("clearing twice: %v", err)
+	}
+	if active, err := LoadActiveSession(path); err != nil || active != nil {
+		t.Errorf("got %+v, %v after clearing", active, err)
+	}
+}
@@ -0,0 +1,128 @@
+package cove
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultStatusTemplate is used by `cove status` when no template is given
+const DefaultStatusTemplate = `{{if .Running}}{{if .Paused}}⏸ {{end}}{{if .Stopwatch}}{{.Elapsed}}{{else if .Over}}+{{.Overtime}}{{else}}{{.Remaining}}{{end}} {{.Description}}{{end}}`
+
+// Status is a snapshot of the active session for status bars. It is the
+// data passed to user-defined status templates.
+type Status struct {
+	Running     bool
+	Paused      bool
+	Description string
+	File        string
+	Line        int
+	Elapsed     string
+	Remaining   string
+	Estimate    string
+	// Stopwatch is set for todos without an estimate, which count up
+	Stopwatch bool
+	// Over is set once the estimate is used up; Overtime is how far past it
+	Over     bool
+	Overtime string
+	// Percent is how much of the estimate has been used, 0-100
+	Percent int
+}
+
+// NewStatus describes active, which may be nil when no timer is running
+func NewStatus(active *ActiveSession) Status {
+	if active == nil {
+		return Status{}
+	}
+
+	percent := 100
+	if active.Estimate > 0 {
+		percent = int(active.Elapsed() * 100 / active.Estimate)
+		if percent > 100 {
+			percent = 100
+		}
+	}
+
+	return Status{
+		Running:     true,
+		Paused:      active.Paused,
+		Description: active.Description,
+		File:        active.File,
+		Line:        active.Line,
+		Elapsed:     formatClock(active.Elapsed()),
+		Remaining:   formatClock(active.Remaining()),
+		Estimate:    formatClock(active.Estimate),
+		Stopwatch:   active.Estimate <= 0,
+		Over:        active.Overtime() > 0,
+		Overtime:    formatClock(active.Overtime()),
+		Percent:     percent,
+	}
+}
+
+// Render executes a text/template against the status
+func (s Status) Render(text string) (string, error) {
+	tmpl, err := template.New("status").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid status template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, s); err != nil {
+		return "", fmt.Errorf("error rendering status: %w", err)
+	}
+	return out.String(), nil
+}
+
+// I3Block is a block for i3bar-compatible status lines (i3blocks, i3status-rust)
+type I3Block struct {
+	FullText  string `json:"full_text"`
+	ShortText string `json:"short_text,omitempty"`
+	Color     string `json:"color,omitempty"`
+}
+
+func (s Status) I3Block(text string) I3Block {
+	block := I3Block{FullText: text}
+	if s.Running {
+		block.ShortText = s.Remaining
+		block.Color = "#F25D94"
+		if s.Paused {
+			block.Color = "#888888"
+		}
+	}
+	return block
+}
+
+// WaybarModule is the JSON accepted by waybar custom modules with
+// "return-type": "json"
+type WaybarModule struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+func (s Status) Waybar(text string) WaybarModule {
+	module := WaybarModule{Text: text, Class: "idle"}
+	if s.Running {
+		module.Tooltip = fmt.Sprintf("%s\n%s of %s", s.Description, s.Elapsed, s.Estimate)
+		module.Class = "running"
+		if s.Paused {
+			module.Class = "paused"
+		}
+		module.Percentage = s.Percent
+	}
+	return module
+}
+
+// formatClock renders a duration as MM:SS, or H:MM:SS past an hour
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	hours := int(d / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := int(d % time.Minute / time.Second)
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
@@ -0,0 +1,84 @@
+package cove
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/stopwatch"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// counting reports whether the work session counts up on a stopwatch
+// rather than down from an estimate
+func (m TimerModel) counting() bool {
+	return m.phase == phaseWork && m.active == nil && m.session.Estimate <= 0
+}
+
+// updateStopwatch handles a session without an estimate. There is nothing
+// to run out, so it goes on until it is stopped. The stopwatch only drives
+// the ticks; the time shown and recorded comes from the session.
+func (m TimerModel) updateStopwatch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keys := m.parentModel.keys
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.confirmingQuit {
+			return m.answerQuit(msg)
+		}
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m.requestQuit()
+		case key.Matches(msg, keys.Help):
+			m.parentModel.help.ShowAll = !m.parentModel.help.ShowAll
+		case key.Matches(msg, keys.Pause):
+			if m.session.Paused {
+				m.session.Resume()
+				m = m.saveState()
+				m.runHook(EventResumed, nil)
+				return m, m.stopwatch.Start()
+			}
+			m.session.Pause()
+			m = m.saveState()
+			m.runHook(EventPaused, nil)
+			return m, m.stopwatch.Stop()
+		case key.Matches(msg, keys.Switch):
+			return m.switchTodo()
+		case key.Matches(msg, keys.Done):
+			return m.leave(true)
+		}
+		return m, nil
+
+	case stopwatch.TickMsg:
+		if msg.ID == m.stopwatch.ID() {
+			m = m.checkpoint()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.stopwatch, cmd = m.stopwatch.Update(msg)
+	return m, cmd
+}
+
+func (m TimerModel) stopwatchView() string {
+	var s strings.Builder
+	keys := m.parentModel.keys
+	theme := m.parentModel.theme
+
+	s.WriteString(m.clockView(formatClock(m.session.Elapsed()), theme.Timer))
+	s.WriteString("\n\n")
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	if m.session.Paused {
+		s.WriteString(statusStyle.Render("⏸️ PAUSED"))
+	} else {
+		s.WriteString(statusStyle.Render("elapsed"))
+	}
+
+	s.WriteString("\n\n")
+
+	s.WriteString(m.parentModel.help.View(keys.timerHelp()))
+
+	return s.String()
+}
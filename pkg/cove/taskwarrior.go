@@ -0,0 +1,222 @@
+package cove
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Taskwarrior and Timewarrior both use compact UTC timestamps
+const warriorTimeFormat = "20060102T150405Z"
+
+// TaskwarriorTask is one task in the format of `task export` / `task import`.
+// Estimate and Spent are user defined attributes (UDAs) holding ISO 8601
+// durations; add `uda.estimate.type=duration` and `uda.spent.type=duration`
+// to your taskrc to keep them.
+type TaskwarriorTask struct {
+	UUID        string   `json:"uuid,omitempty"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Entry       string   `json:"entry,omitempty"`
+	End         string   `json:"end,omitempty"`
+	Due         string   `json:"due,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Estimate    string   `json:"estimate,omitempty"`
+	Spent       string   `json:"spent,omitempty"`
+}
+
+// TimewarriorInterval is one tracked interval in the format of
+// `timew export` / `timew import`
+type TimewarriorInterval struct {
+	Start string   `json:"start"`
+	End   string   `json:"end,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// ToTaskwarrior converts todos into Taskwarrior tasks. Tags and due dates
+// move out of the description into their own fields. Done todos end when
+// the session that completed them did; without one they have no end, so
+// exporting again doesn't change the task.
+func ToTaskwarrior(filename string, todos []Todo, sessions []Session) []TaskwarriorTask {
+	tasks := make([]TaskwarriorTask, 0, len(todos))
+	for _, todo := range todos {
+		task := TaskwarriorTask{
+			UUID:        warriorUUID(absPath(filename), strconv.Itoa(todo.LineNumber), plainDescription(todo.Description)),
+			Description: plainDescription(todo.Description),
+			Status:      "pending",
+			Tags:        todo.Tags,
+			Estimate:    isoDuration(todo.EstimatedTime),
+		}
+		if todo.State == Done {
+			task.Status = "completed"
+			if end := completedAt(todo, sessions); !end.IsZero() {
+				task.End = end.UTC().Format(warriorTimeFormat)
+			}
+		}
+		if !todo.Due.IsZero() {
+			task.Due = todo.Due.UTC().Format(warriorTimeFormat)
+		}
+		if todo.TimeSpent > 0 {
+			task.Spent = isoDuration(todo.TimeSpent)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// completedAt is the end of the latest session that marked todo done. The
+// line tells apart todos with the same description.
+func completedAt(todo Todo, sessions []Session) time.Time {
+	var end time.Time
+	for _, session := range sessions {
+		if session.Completed && session.Line == todo.LineNumber && session.Description == todo.Description &&
+			session.End.After(end) {
+			end = session.End
+		}
+	}
+	return end
+}
+
+// FromTaskwarrior converts Taskwarrior tasks into todos ready to be appended
+// to a markdown file, writing their estimates as stars. Deleted tasks are
+// skipped.
+func FromTaskwarrior(tasks []TaskwarriorTask, estimates Estimates) []Todo {
+	var todos []Todo
+	for _, task := range tasks {
+		if task.Status == "deleted" || strings.TrimSpace(task.Description) == "" {
+			continue
+		}
+
+		todo := NewTodo(strings.TrimSpace(task.Description))
+		todo.EstimatedTime = estimates.Default
+		if task.Status == "completed" {
+			todo.State = Done
+		}
+		if estimate, err := parseISODuration(task.Estimate); err == nil && estimate > 0 {
+			todo.SetEstimate(estimate, estimates)
+		}
+		if spent, err := parseISODuration(task.Spent); err == nil {
+			todo.TimeSpent = spent
+		}
+
+		for _, tag := range task.Tags {
+			todo.Tags = append(todo.Tags, tag)
+			todo.Description += " #" + tag
+		}
+		if due, err := time.Parse(warriorTimeFormat, task.Due); err == nil {
+			todo.Due = due.Local()
+			todo.Description += " due:" + todo.Due.Format("2006-01-02")
+		}
+
+		todos = append(todos, todo)
+	}
+	return todos
+}
+
+// ToTimewarrior converts sessions into Timewarrior intervals, tagged with
+// the todo description followed by its #tags
+func ToTimewarrior(sessions []Session) []TimewarriorInterval {
+	intervals := make([]TimewarriorInterval, 0, len(sessions))
+	for _, session := range sessions {
+		tags := []string{plainDescription(session.Description)}
+		for _, tagMatch := range tagRegex.FindAllStringSubmatch(session.Description, -1) {
+			tags = append(tags, tagMatch[1])
+		}
+		intervals = append(intervals, TimewarriorInterval{
+			Start: session.Start.UTC().Format(warriorTimeFormat),
+			End:   session.End.UTC().Format(warriorTimeFormat),
+			Tags:  tags,
+		})
+	}
+	return intervals
+}
+
+// FromTimewarrior converts closed Timewarrior intervals into sessions for
+// filename. The first tag is used as the description, matching ToTimewarrior.
+func FromTimewarrior(intervals []TimewarriorInterval, filename string) []Session {
+	var sessions []Session
+	for _, interval := range intervals {
+		start, err := time.Parse(warriorTimeFormat, interval.Start)
+		if err != nil {
+			continue
+		}
+		// Still-running intervals have no end yet
+		end, err := time.Parse(warriorTimeFormat, interval.End)
+		if err != nil {
+			continue
+		}
+
+		description := "untagged"
+		if len(interval.Tags) > 0 {
+			description = interval.Tags[0]
+		}
+		sessions = append(sessions, Session{
+			Description: description,
+			File:        absPath(filename),
+			Start:       start.Local(),
+			End:         end.Local(),
+			Duration:    end.Sub(start),
+		})
+	}
+	return sessions
+}
+
+// DecodeWarriorJSON reads either a JSON array or one JSON object per line,
+// since Taskwarrior has emitted both over the years
+func DecodeWarriorJSON(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] == '[' {
+		if len(trimmed) == 0 {
+			trimmed = []byte("[]")
+		}
+		if err := json.Unmarshal(trimmed, v); err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+		return nil
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(trimmed)+1)
+	for scanner.Scan() {
+		line := bytes.TrimRight(bytes.TrimSpace(scanner.Bytes()), ",")
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	array := append([]byte("["), bytes.Join(lines, []byte(","))...)
+	array = append(array, ']')
+	if err := json.Unmarshal(array, v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// plainDescription strips cove-specific #tags and due: markers
+func plainDescription(description string) string {
+	description = tagRegex.ReplaceAllString(description, "")
+	description = dueRegex.ReplaceAllString(description, "")
+	return strings.Join(strings.Fields(description), " ")
+}
+
+// warriorUUID derives a stable UUID so re-exporting the same todo updates
+// the existing task instead of creating a duplicate. Todos are keyed by
+// file, line and description without tags and due dates, so todos with the
+// same description stay apart and retagging one keeps its task.
+func warriorUUID(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	sum[6] = (sum[6] & 0x0f) | 0x50 // version 5
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
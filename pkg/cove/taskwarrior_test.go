@@ -0,0 +1,206 @@
+package cove
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToTaskwarrior(t *testing.T) {
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 4, 30, 17, 30, 0, 0, time.UTC)
+	todos := []Todo{
+		{Description: "Fix login #bug due:2024-05-01", LineNumber: 1, EstimatedTime: 15 * time.Minute, Tags: []string{"bug"}, Due: due},
+		{Description: "Review PR", LineNumber: 2, State: Done, EstimatedTime: 10 * time.Minute, TimeSpent: 12 * time.Minute},
+		{Description: "Old chore", LineNumber: 3, State: Done, EstimatedTime: 20 * time.Minute},
+		{Description: "Review PR", LineNumber: 4, State: Done, EstimatedTime: 10 * time.Minute},
+	}
+	sessions := []Session{
+		{Description: "Review PR", Line: 2, End: end.Add(-time.Hour), Completed: false},
+		{Description: "Review PR", Line: 2, End: end, Completed: true},
+		{Description: "Review PR", Line: 4, End: end.Add(time.Hour), Completed: true},
+	}
+
+	tests := []struct {
+		name string
+		want TaskwarriorTask
+	}{
+		{
+			name: "pending",
+			want: TaskwarriorTask{Description: "Fix login", Status: "pending", Due: "20240501T000000Z", Tags: []string{"bug"}, Estimate: "PT15M"},
+		},
+		{
+			name: "completed by a session",
+			want: TaskwarriorTask{Description: "Review PR", Status: "completed", End: "20240430T173000Z", Estimate: "PT10M", Spent: "PT12M"},
+		},
+		{
+			name: "completed without a session",
+			want: TaskwarriorTask{Description: "Old chore", Status: "completed", Estimate: "PT20M"},
+		},
+		{
+			name: "same description on another line",
+			want: TaskwarriorTask{Description: "Review PR", Status: "completed", End: "20240430T183000Z", Estimate: "PT10M"},
+		},
+	}
+
+	tasks := ToTaskwarrior("/tmp/todos.md", todos, sessions)
+	if len(tasks) != len(tests) {
+		t.Fatalf("got %d tasks, want %d", len(tasks), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tasks[i]
+			if got.UUID == "" {
+				t.Error("no UUID")
+			}
+			got.UUID = ""
+			if got.Description != tt.want.Description || got.Status != tt.want.Status ||
+				got.End != tt.want.End || got.Due != tt.want.Due || got.Estimate != tt.want.Estimate ||
+				got.Spent != tt.want.Spent || !slices.Equal(got.Tags, tt.want.Tags) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if tasks[1].UUID == tasks[3].UUID {
+		t.Errorf("todos with the same description share UUID %s", tasks[1].UUID)
+	}
+
+	// Retagging a todo keeps its task
+	todos[0].Description = "Fix login #auth due:2024-05-02"
+	again := ToTaskwarrior("/tmp/todos.md", todos, sessions)
+	for i := range tasks {
+		if again[i].UUID != tasks[i].UUID || again[i].End != tasks[i].End {
+			t.Errorf("exporting again changed %+v to %+v", tasks[i], again[i])
+		}
+	}
+}
+
+func TestFromTaskwarrior(t *testing.T) {
+	estimates := DefaultEstimates()
+	tests := []struct {
+		name  string
+		task  TaskwarriorTask
+		want  Todo
+		empty bool
+	}{
+		{
+			name: "estimate rounded up to stars",
+			task: TaskwarriorTask{Description: "Write docs", Status: "pending", Estimate: "PT12M"},
+			want: Todo{Description: "Write docs", Stars: 3, EstimatedTime: 15 * time.Minute},
+		},
+		{
+			name: "no estimate",
+			task: TaskwarriorTask{Description: " Tidy up ", Status: "pending"},
+			want: Todo{Description: "Tidy up", EstimatedTime: 20 * time.Minute},
+		},
+		{
+			name: "completed with time spent",
+			task: TaskwarriorTask{Description: "Ship it", Status: "completed", Spent: "PT1H5M"},
+			want: Todo{Description: "Ship it", State: Done, EstimatedTime: 20 * time.Minute, TimeSpent: 65 * time.Minute},
+		},
+		{
+			name: "tags and due date",
+			task: TaskwarriorTask{Description: "Fix login", Status: "pending", Tags: []string{"bug"}, Due: "20240501T120000Z"},
+			want: Todo{
+				Description:   "Fix login #bug due:" + time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Local().Format("2006-01-02"),
+				EstimatedTime: 20 * time.Minute,
+				Tags:          []string{"bug"},
+				Due:           time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "deleted",
+			task:  TaskwarriorTask{Description: "Gone", Status: "deleted"},
+			empty: true,
+		},
+		{
+			name:  "empty description",
+			task:  TaskwarriorTask{Description: "  ", Status: "pending"},
+			empty: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos := FromTaskwarrior([]TaskwarriorTask{tt.task}, estimates)
+			if tt.empty {
+				if len(todos) != 0 {
+					t.Fatalf("got %+v, want nothing", todos)
+				}
+				return
+			}
+			if len(todos) != 1 {
+				t.Fatalf("got %d todos, want 1", len(todos))
+			}
+			got := todos[0]
+			if got.Description != tt.want.Description || got.State != tt.want.State ||
+				got.Stars != tt.want.Stars || got.EstimatedTime != tt.want.EstimatedTime ||
+				got.TimeSpent != tt.want.TimeSpent || !got.Due.Equal(tt.want.Due) ||
+				!slices.Equal(got.Tags, tt.want.Tags) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimewarriorRoundTrip(t *testing.T) {
+	start := time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC)
+	sessions := []Session{{
+		Description: "Fix login #bug #auth",
+		Start:       start,
+		End:         start.Add(25 * time.Minute),
+		Duration:    25 * time.Minute,
+	}}
+
+	intervals := ToTimewarrior(sessions)
+	want := TimewarriorInterval{Start: "20240430T090000Z", End: "20240430T092500Z", Tags: []string{"Fix login", "bug", "auth"}}
+	if len(intervals) != 1 || intervals[0].Start != want.Start || intervals[0].End != want.End ||
+		!slices.Equal(intervals[0].Tags, want.Tags) {
+		t.Fatalf("got %+v, want %+v", intervals, want)
+	}
+
+	back := FromTimewarrior(append(intervals, TimewarriorInterval{Start: "20240430T100000Z"}), "/tmp/todos.md")
+	if len(back) != 1 {
+		t.Fatalf("got %d sessions, want 1 without the open interval", len(back))
+	}
+	if back[0].Description != "Fix login" || !back[0].Start.Equal(start) ||
+		back[0].Duration != 25*time.Minute || back[0].File != "/tmp/todos.md" {
+		t.Errorf("got %+v", back[0])
+	}
+}
+
+func TestDecodeWarriorJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "array", input: `[{"description":"a"},{"description":"b"}]`, want: []string{"a", "b"}},
+		{name: "lines", input: "{\"description\":\"a\"}\n{\"description\":\"b\"}\n", want: []string{"a", "b"}},
+		{name: "lines with commas", input: "{\"description\":\"a\"},\n{\"description\":\"b\"}", want: []string{"a", "b"}},
+		{name: "empty", input: "  \n", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tasks []TaskwarriorTask
+			if err := DecodeWarriorJSON(strings.NewReader(tt.input), &tasks); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Description)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var tasks []TaskwarriorTask
+	if err := DecodeWarriorJSON(strings.NewReader("{not json"), &tasks); err == nil {
+		t.Error("invalid JSON was accepted")
+	}
+}
@@ -0,0 +1,250 @@
+package cove
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// ThemeColor is a color from the config: either a single "#RRGGBB" or ANSI
+// color number, or a { light = ..., dark = ... } table picked by the
+// terminal's background
+type ThemeColor struct {
+	Light string
+	Dark  string
+}
+
+func (c *ThemeColor) UnmarshalTOML(value any) error {
+	switch v := value.(type) {
+	case string:
+		c.Light, c.Dark = v, v
+		return nil
+	case map[string]any:
+		for key, color := range v {
+			s, ok := color.(string)
+			if !ok {
+				return fmt.Errorf("color %q must be a string", key)
+			}
+			switch key {
+			case "light":
+				c.Light = s
+			case "dark":
+				c.Dark = s
+			default:
+				return fmt.Errorf("unknown color variant %q (have: light, dark)", key)
+			}
+		}
+		if c.Light == "" || c.Dark == "" {
+			return fmt.Errorf("adaptive colors need both light and dark")
+		}
+		return nil
+	}
+	return fmt.Errorf("a color must be a string or a { light, dark } table")
+}
+
+func (c ThemeColor) color() lipgloss.TerminalColor {
+	if c.Light == c.Dark {
+		return lipgloss.Color(c.Dark)
+	}
+	return lipgloss.AdaptiveColor{Light: c.Light, Dark: c.Dark}
+}
+
+// Palette is the set of colors a theme defines. Unset colors are taken from
+// the theme it is based on.
+type Palette struct {
+	// Accent is the background of titles and the spinner
+	Accent ThemeColor `toml:"accent"`
+	// Highlight marks the selected todo and finished timers
+	Highlight ThemeColor `toml:"highlight"`
+	// Timer is the background of the countdown and Overtime of the time
+	// worked past the estimate
+	Timer    ThemeColor `toml:"timer"`
+	Overtime ThemeColor `toml:"overtime"`
+	// Text is regular text; Contrast is text on Accent and Timer
+	Text     ThemeColor `toml:"text"`
+	Contrast ThemeColor `toml:"contrast"`
+	Muted    ThemeColor `toml:"muted"`
+	Subtle   ThemeColor `toml:"subtle"`
+}
+
+// ThemeConfig is the [theme] table: a theme name plus colors overriding it
+type ThemeConfig struct {
+	Name string `toml:"name"`
+	Palette
+}
+
+// UserTheme is a [themes.NAME] table defining a theme of its own
+type UserTheme struct {
+	// Base is the built-in theme supplying colors the user theme leaves out
+	Base string `toml:"base"`
+	Palette
+}
+
+func fixedPalette(accent, highlight, timer, overtime, text, contrast, muted, subtle string) Palette {
+	fixed := func(c string) ThemeColor { return ThemeColor{Light: c, Dark: c} }
+	return Palette{
+		Accent:    fixed(accent),
+		Highlight: fixed(highlight),
+		Timer:     fixed(timer),
+		Overtime:  fixed(overtime),
+		Text:      fixed(text),
+		Contrast:  fixed(contrast),
+		Muted:     fixed(muted),
+		Subtle:    fixed(subtle),
+	}
+}
+
+var (
+	darkPalette  = fixedPalette("#7D56F4", "#01BE85", "#F25D94", "#FF8700", "#FAFAFA", "#FAFAFA", "#888888", "#555555")
+	lightPalette = fixedPalette("#5A3FD0", "#00875A", "#C2185B", "#D75F00", "#1A1A1A", "#FFFFFF", "#6B6B6B", "#9E9E9E")
+)
+
+// builtinThemes are always available. "auto" follows the terminal's
+// background and "mono" uses no color at all.
+var builtinThemes = map[string]Palette{
+	"auto":  adaptivePalette(lightPalette, darkPalette),
+	"dark":  darkPalette,
+	"light": lightPalette,
+	"high-contrast": adaptivePalette(
+		fixedPalette("#000000", "#005F00", "#AF0000", "#875F00", "#000000", "#FFFFFF", "#000000", "#303030"),
+		fixedPalette("#FFFFFF", "#00FF00", "#FF5F5F", "#FFAF00", "#FFFFFF", "#000000", "#FFFFFF", "#D0D0D0"),
+	),
+	"mono": {},
+}
+
+func adaptivePalette(light, dark Palette) Palette {
+	both := func(l, d ThemeColor) ThemeColor { return ThemeColor{Light: l.Light, Dark: d.Dark} }
+	return Palette{
+		Accent:    both(light.Accent, dark.Accent),
+		Highlight: both(light.Highlight, dark.Highlight),
+		Timer:     both(light.Timer, dark.Timer),
+		Overtime:  both(light.Overtime, dark.Overtime),
+		Text:      both(light.Text, dark.Text),
+		Contrast:  both(light.Contrast, dark.Contrast),
+		Muted:     both(light.Muted, dark.Muted),
+		Subtle:    both(light.Subtle, dark.Subtle),
+	}
+}
+
+// merge returns p with every color set in overrides replaced
+func (p Palette) merge(overrides Palette) Palette {
+	pick := func(base, override ThemeColor) ThemeColor {
+		if override == (ThemeColor{}) {
+			return base
+		}
+		return override
+	}
+	return Palette{
+		Accent:    pick(p.Accent, overrides.Accent),
+		Highlight: pick(p.Highlight, overrides.Highlight),
+		Timer:     pick(p.Timer, overrides.Timer),
+		Overtime:  pick(p.Overtime, overrides.Overtime),
+		Text:      pick(p.Text, overrides.Text),
+		Contrast:  pick(p.Contrast, overrides.Contrast),
+		Muted:     pick(p.Muted, overrides.Muted),
+		Subtle:    pick(p.Subtle, overrides.Subtle),
+	}
+}
+
+// colors lists the palette's colors by config name
+func (p Palette) colors() map[string]ThemeColor {
+	return map[string]ThemeColor{
+		"accent":    p.Accent,
+		"highlight": p.Highlight,
+		"timer":     p.Timer,
+		"overtime":  p.Overtime,
+		"text":      p.Text,
+		"contrast":  p.Contrast,
+		"muted":     p.Muted,
+		"subtle":    p.Subtle,
+	}
+}
+
+// validate reports colors that are set but not valid
+func (p Palette) validate(prefix string) []error {
+	colors := p.colors()
+	var problems []error
+	for _, name := range sortedKeys(colors) {
+		c := colors[name]
+		if c == (ThemeColor{}) {
+			continue
+		}
+		for _, value := range []string{c.Light, c.Dark} {
+			if !validColor(value) {
+				problems = append(problems, fmt.Errorf("%s.%s: invalid color %q", prefix, name, value))
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// Theme is the resolved set of styles the TUI draws with
+type Theme struct {
+	Accent    lipgloss.TerminalColor
+	Highlight lipgloss.TerminalColor
+	Timer     lipgloss.TerminalColor
+	Overtime  lipgloss.TerminalColor
+	Text      lipgloss.TerminalColor
+	Contrast  lipgloss.TerminalColor
+	Muted     lipgloss.TerminalColor
+	Subtle    lipgloss.TerminalColor
+	// Monochrome themes draw badges in reverse video instead of colors
+	Monochrome bool
+}
+
+// NewTheme resolves the configured theme. NO_COLOR and terminals without
+// color support always get the monochrome theme.
+func NewTheme(config Config) Theme {
+	name := config.Theme.Name
+	if os.Getenv("NO_COLOR") != "" || lipgloss.ColorProfile() == termenv.Ascii {
+		name = "mono"
+	}
+	if name == "mono" {
+		none := lipgloss.NoColor{}
+		return Theme{
+			Accent: none, Highlight: none, Timer: none, Overtime: none, Text: none,
+			Contrast: none, Muted: none, Subtle: none,
+			Monochrome: true,
+		}
+	}
+
+	palette, ok := builtinThemes[name]
+	// User themes based on mono keep its reverse-video badges, which stay
+	// readable whichever colors they add
+	monochrome := false
+	if user, isUser := config.Themes[name]; isUser && !ok {
+		base := user.Base
+		if base == "" {
+			base = "auto"
+		}
+		palette = builtinThemes[base].merge(user.Palette)
+		monochrome = base == "mono"
+	} else if !ok {
+		palette = builtinThemes["auto"]
+	}
+	palette = palette.merge(config.Theme.Palette)
+
+	return Theme{
+		Accent:     palette.Accent.color(),
+		Highlight:  palette.Highlight.color(),
+		Timer:      palette.Timer.color(),
+		Overtime:   palette.Overtime.color(),
+		Text:       palette.Text.color(),
+		Contrast:   palette.Contrast.color(),
+		Muted:      palette.Muted.color(),
+		Subtle:     palette.Subtle.color(),
+		Monochrome: monochrome,
+	}
+}
+
+// Badge is text drawn on a colored background, like the title bar
+func (t Theme) Badge(background lipgloss.TerminalColor) lipgloss.Style {
+	style := lipgloss.NewStyle().Bold(true)
+	if t.Monochrome {
+		return style.Reverse(true)
+	}
+	return style.Foreground(t.Contrast).Background(background)
+}
@@ -21,6 +21,29 @@ func (s TodoState) String() string {
 	return "unknown"
 }
 
+// Estimates turn the star hints after a todo into an estimate: each "*"
+// adds Star, and a todo without stars gets Default. They come from the
+// config file.
+type Estimates struct {
+	Star    time.Duration
+	Default time.Duration
+}
+
+// DefaultEstimates are used when the config doesn't change them
+func DefaultEstimates() Estimates {
+	return Estimates{Star: 5 * time.Minute, Default: 20 * time.Minute}
+}
+
+// Stars returns how many stars an estimate takes, rounding up
+func (e Estimates) Stars(estimate time.Duration) int {
+	return int((estimate + e.Star - 1) / e.Star)
+}
+
+// Round rounds an estimate up to a whole number of stars
+func (e Estimates) Round(estimate time.Duration) time.Duration {
+	return time.Duration(e.Stars(estimate)) * e.Star
+}
+
 type Todo struct {
 	Description    string
 	State          TodoState
@@ -28,6 +51,14 @@ type Todo struct {
 	EstimatedTime  time.Duration
 	OriginalLine   string
 	LineNumber     int
+	Tags           []string
+	Due            time.Time
+	People         []string
+	// Section is the heading the todo is under, if any
+	Section        string
+	// Stars is how many star hints the todo is written with; without any
+	// it has the default estimate
+	Stars          int
 }
 
 func NewTodo(description string) Todo {
@@ -35,23 +66,40 @@ func NewTodo(description string) Todo {
 		Description:   description,
 		State:         Open,
 		TimeSpent:     0,
-		EstimatedTime: 20 * time.Minute, // default 20 minutes
+		EstimatedTime: DefaultEstimates().Default,
 		OriginalLine:  "",
 		LineNumber:    0,
 	}
 }
 
+// NewTodoWithEstimate returns a todo with the stars to write down its
+// estimate on the default scale
 func NewTodoWithEstimate(description string, estimatedMinutes int) Todo {
+	estimate := time.Duration(estimatedMinutes) * time.Minute
 	return Todo{
 		Description:   description,
 		State:         Open,
 		TimeSpent:     0,
-		EstimatedTime: time.Duration(estimatedMinutes) * time.Minute,
+		EstimatedTime: estimate,
 		OriginalLine:  "",
 		LineNumber:    0,
+		Stars:         DefaultEstimates().Stars(estimate),
 	}
 }
 
+// SetEstimate gives the todo an estimate, rounded up to whole stars since
+// that is how it is written down
+func (t *Todo) SetEstimate(estimate time.Duration, estimates Estimates) {
+	t.Stars = estimates.Stars(estimate)
+	t.EstimatedTime = time.Duration(t.Stars) * estimates.Star
+}
+
+// HasEstimate is false for todos marked "est:none", which count up instead
+// of down
+func (t Todo) HasEstimate() bool {
+	return t.EstimatedTime > 0
+}
+
 func (t *Todo) MarkDone() {
 	t.State = Done
 }
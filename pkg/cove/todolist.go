@@ -0,0 +1,221 @@
+package cove
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Until the terminal reports its size, the selector assumes this one
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// todoItem is a todo in the selector's list. It points into the selector's
+// todos, so time saved by the timer shows up right away.
+type todoItem struct {
+	todo  *Todo
+	index int
+}
+
+// FilterValue is what the fuzzy search matches against
+func (i todoItem) FilterValue() string { return i.todo.Description }
+
+// todoDelegate draws a todo as its markdown line followed by the time
+// spent, underlining the characters a search matched. Todos take one line
+// each so that long lists fit on few pages.
+type todoDelegate struct {
+	theme Theme
+}
+
+func (d todoDelegate) Height() int                         { return 1 }
+func (d todoDelegate) Spacing() int                        { return 0 }
+func (d todoDelegate) Update(tea.Msg, *list.Model) tea.Cmd { return nil }
+
+func (d todoDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	todo := item.(todoItem).todo
+	selected := index == m.Index()
+
+	checkbox := "[ ]"
+	if todo.State == Done {
+		checkbox = "[x]"
+	} else if todo.TimeSpent > 0 {
+		checkbox = "[*]"
+	}
+
+	lineStyle := lipgloss.NewStyle()
+	timeStyle := lipgloss.NewStyle().Foreground(d.theme.Muted)
+	prefix := "  "
+	if selected {
+		lineStyle = lineStyle.Foreground(d.theme.Highlight).Bold(true)
+		timeStyle = lipgloss.NewStyle().Foreground(d.theme.Highlight)
+		prefix = "> "
+	}
+
+	description := todo.Description
+	if matches := m.MatchesForItem(index); len(matches) > 0 {
+		description = lipgloss.StyleRunes(description, matches, lineStyle.Underline(true), lineStyle)
+	} else {
+		description = lineStyle.Render(description)
+	}
+	fmt.Fprint(w, prefix+lineStyle.Render("- "+checkbox+" ")+description)
+
+	if todo.TimeSpent > 0 {
+		fmt.Fprint(w, timeStyle.Render(fmt.Sprintf("  spent: %v", todo.TimeSpent.Round(time.Minute))))
+	}
+}
+
+// newTodoList sets up the list component with the user's keys and colors.
+// The selector draws its own title and help, and handles quitting.
+func newTodoList(keys KeyMap, theme Theme) list.Model {
+	l := list.New(nil, todoDelegate{theme: theme}, defaultWidth, defaultHeight)
+	l.SetShowTitle(false)
+	l.SetShowHelp(false)
+	l.SetStatusBarItemName("todo", "todos")
+	l.DisableQuitKeybindings()
+
+	l.KeyMap.CursorUp = keys.Up
+	l.KeyMap.CursorDown = keys.Down
+	l.KeyMap.Filter = keys.Search
+	l.KeyMap.ShowFullHelp = key.NewBinding()
+	l.KeyMap.CloseFullHelp = key.NewBinding()
+	l.KeyMap.ClearFilter.SetHelp("esc", "clear search")
+	l.KeyMap.AcceptWhileFiltering.SetHelp("enter", "done searching")
+	l.KeyMap.CancelWhileFiltering.SetHelp("esc", "cancel")
+
+	l.FilterInput.Prompt = "Search: "
+	l.FilterInput.PromptStyle = lipgloss.NewStyle().Foreground(theme.Accent)
+	l.FilterInput.Cursor.Style = lipgloss.NewStyle().Foreground(theme.Accent)
+	l.Styles.TitleBar = lipgloss.NewStyle()
+	l.Styles.StatusBar = lipgloss.NewStyle().Foreground(theme.Muted).Padding(0, 0, 1, 0)
+	l.Styles.StatusEmpty = lipgloss.NewStyle().Foreground(theme.Subtle)
+	l.Styles.StatusBarActiveFilter = lipgloss.NewStyle().Foreground(theme.Text)
+	l.Styles.StatusBarFilterCount = lipgloss.NewStyle().Foreground(theme.Subtle)
+	l.Styles.NoItems = lipgloss.NewStyle().Foreground(theme.Muted)
+	l.Styles.ActivePaginationDot = lipgloss.NewStyle().Foreground(theme.Accent).SetString("•")
+	l.Styles.InactivePaginationDot = lipgloss.NewStyle().Foreground(theme.Subtle).SetString("•")
+	l.Paginator.ActiveDot = l.Styles.ActivePaginationDot.String()
+	l.Paginator.InactiveDot = l.Styles.InactivePaginationDot.String()
+	return l
+}
+
+// setItems fills the list with the todos that pass the current saved
+// filter, keeping the selected todo selected when it still shows
+func (m TodoSelectorModel) setItems() TodoSelectorModel {
+	var line int
+	var description string
+	if selected, ok := m.list.SelectedItem().(todoItem); ok {
+		line, description = selected.todo.LineNumber, selected.todo.Description
+	}
+
+	var items []list.Item
+	for i := range m.todos {
+		if m.filter < 0 || m.filters[m.filter].Match(m.todos[i]) {
+			items = append(items, todoItem{todo: &m.todos[i], index: i})
+		}
+	}
+	// A search in progress is matched against the new items right away,
+	// rather than leaving the list empty until the matches come back
+	if cmd := m.list.SetItems(items); cmd != nil {
+		m.list, _ = m.list.Update(cmd())
+	}
+
+	if description != "" && !m.list.IsFiltered() {
+		for i, item := range items {
+			todo := item.(todoItem).todo
+			if todo.LineNumber == line && todo.Description == description {
+				m.list.Select(i)
+				break
+			}
+		}
+	}
+	return m
+}
+
+// selected returns the index in todos of the todo under the cursor
+func (m TodoSelectorModel) selected() (int, bool) {
+	item, ok := m.list.SelectedItem().(todoItem)
+	return item.index, ok
+}
+
+// nextFilter cycles through the saved filters, then back to all todos
+func (m TodoSelectorModel) nextFilter() TodoSelectorModel {
+	if len(m.filters) == 0 {
+		return m
+	}
+	m.filter++
+	if m.filter >= len(m.filters) {
+		m.filter = -1
+	}
+	return m.setItems()
+}
+
+// setSize fits the list between the header and the help, in a terminal of
+// the given size
+func (m TodoSelectorModel) setSize(width, height int) TodoSelectorModel {
+	m.width, m.height = width, height
+	return m.resize()
+}
+
+// resize gives the list whatever height the header and help leave over,
+// which changes as the help is expanded or a search is typed
+func (m TodoSelectorModel) resize() TodoSelectorModel {
+	width, height := m.width, m.height
+	if width <= 0 || height <= 0 {
+		width, height = defaultWidth, defaultHeight
+	}
+	// The help is set off from the list by a blank line
+	used := strings.Count(m.headerView(), "\n") + 1 + lipgloss.Height(m.helpView())
+	m.list.SetSize(width, max(height-used, 1))
+	return m
+}
+
+// headerView is the title, with the saved filter in use, and the daily goal
+func (m TodoSelectorModel) headerView() string {
+	var s strings.Builder
+
+	titleStyle := m.theme.Badge(m.theme.Accent).
+		Padding(0, 1)
+	s.WriteString(titleStyle.Render("📝 TODO Selector"))
+	if m.filter >= 0 {
+		filterStyle := lipgloss.NewStyle().Foreground(m.theme.Muted)
+		s.WriteString(filterStyle.Render("  filter: " + m.filters[m.filter].Name))
+	}
+	s.WriteString("\n\n")
+
+	if goal := m.goalView(0); goal != "" {
+		s.WriteString(goal)
+		s.WriteString("\n\n")
+	}
+	s.WriteString(m.errorView())
+	return s.String()
+}
+
+// fail shows err above the list until the next key is pressed
+func (m TodoSelectorModel) fail(err error) TodoSelectorModel {
+	m.err = err
+	return m.resize()
+}
+
+// errorView shows the last failure, if there is one
+func (m TodoSelectorModel) errorView() string {
+	if m.err == nil {
+		return ""
+	}
+	errorStyle := lipgloss.NewStyle().Foreground(m.theme.Overtime)
+	return errorStyle.Render("⚠️ "+m.err.Error()) + "\n\n"
+}
+
+func (m TodoSelectorModel) helpView() string {
+	if m.list.SettingFilter() {
+		return m.help.View(searchHelp(m.list.KeyMap))
+	}
+	return m.help.View(m.keys.selectorHelp(m.list.KeyMap.ClearFilter))
+}
@@ -1,12 +1,19 @@
 package cove
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/stopwatch"
 	"github.com/charmbracelet/bubbles/timer"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -16,6 +23,18 @@ import (
 type fileChangedMsg struct{}
 type checkFileMsg struct{}
 
+// overtimeTickMsg redraws the overtime of the timer with the given ID
+type overtimeTickMsg struct {
+	id int
+}
+
+// ShutdownMsg asks the TUI to record the running session and quit, as it
+// should when the process gets SIGTERM or SIGHUP
+type ShutdownMsg struct{}
+type daemonEventMsg struct {
+	event  Event
+	closed bool
+}
 
 // Helper function to sort todos (completed items last)
 func sortTodos(todos []Todo) []Todo {
@@ -47,20 +66,58 @@ func sortTodos(todos []Todo) []Todo {
 type TodoSelectorModel struct {
 	todos        []Todo
 	filename     string
+	historyPath  string
+	daemon       *Client
+	keys         KeyMap
+	help         help.Model
+	theme        Theme
+	pollInterval time.Duration
+	estimates    Estimates
+	pomodoro     PomodoroConfig
+	notify       NotifyConfig
+	// terminal is the program's output, where notifications are written
+	terminal     io.Writer
+	tracking     TrackingConfig
+	hooks        *Hooks
+	bus          *EventBus
+	// statePath keeps the locally timed session; recovered is one found
+	// there at startup, waiting for the user to decide what to do with it
+	statePath    string
+	recovered    *ActiveSession
+	// switchedFrom is the session the timer's switch key just recorded,
+	// reported with the next todo started in a switched event
+	switchedFrom *Session
+	// pomodoros counts the work intervals finished since the TUI started
+	pomodoros    int
+	// goal is the daily target and focus adds up the history towards it
+	goal         GoalConfig
+	focus        *FocusLog
 	lastModified time.Time
 	spinner      spinner.Model
 	loading      bool
-	cursor       int
+	// list shows the todos that pass filters[filter], or all of them when
+	// filter is -1, and does the fuzzy search
+	list         list.Model
+	filters      []Filter
+	filter       int
+	width        int
+	height       int
+	// err is the last failure to show, like a session whose time couldn't
+	// be recorded, until the next key is pressed
+	err          error
 }
 
 func NewTodoSelector(todos []Todo, filename string) TodoSelectorModel {
 	// Sort todos (completed items last)
 	sortedTodos := sortTodos(todos)
 	
+	config := DefaultConfig()
+	theme := NewTheme(config)
+	
 	// Create spinner
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(theme.Accent)
 	
 	// Get initial file modification time
 	modTime := time.Time{}
@@ -68,14 +125,126 @@ func NewTodoSelector(todos []Todo, filename string) TodoSelectorModel {
 		modTime = stat.ModTime()
 	}
 	
-	return TodoSelectorModel{
+	// A session left behind by a crash or a closed terminal
+	statePath := TUIStatePath(filename)
+	recovered, err := LoadActiveSession(statePath)
+	if err != nil {
+		err = fmt.Errorf("failed to read the unfinished session: %w", err)
+	}
+	if recovered != nil && !recovered.Orphaned() {
+		// Another TUI is still timing it and will record it itself
+		recovered = nil
+	}
+	
+	m := TodoSelectorModel{
 		todos:        sortedTodos,
 		filename:     filename,
+		historyPath:  config.History,
+		keys:         NewKeyMap(config.Keys),
+		help:         newHelp(theme),
+		theme:        theme,
+		pollInterval: config.Durations.Poll.Duration,
+		estimates:    config.Estimates(),
+		pomodoro:     config.Pomodoro,
+		notify:       config.Notify,
+		terminal:     os.Stdout,
+		tracking:     config.Tracking,
+		statePath:    statePath,
+		recovered:    recovered,
 		lastModified: modTime,
 		spinner:      s,
 		loading:      false,
-		cursor:       0,
+		list:         newTodoList(NewKeyMap(config.Keys), theme),
+		filters:      savedFilters(config.Filters),
+		filter:       -1,
+		err:          err,
+	}
+	return m.setItems().resize()
+}
+
+// WithDaemon makes the timer run inside a `cove daemon`, so it keeps going
+// after the TUI exits
+func (m TodoSelectorModel) WithDaemon(daemon *Client) TodoSelectorModel {
+	m.daemon = daemon
+	return m
+}
+
+// WithTerminal writes notifications to the program's output, which should
+// be a Terminal so they don't interleave with what the renderer writes
+func (m TodoSelectorModel) WithTerminal(terminal io.Writer) TodoSelectorModel {
+	m.terminal = terminal
+	return m
+}
+
+// WithBus publishes typed events for sessions the TUI times itself, changed
+// todos and reloaded files. Sessions run by a daemon are published by the
+// daemon's engine instead.
+func (m TodoSelectorModel) WithBus(bus *EventBus) TodoSelectorModel {
+	m.bus = bus
+	return m
+}
+
+// WithConfig applies the user's keys, colors, timings and history file
+func (m TodoSelectorModel) WithConfig(config Config) TodoSelectorModel {
+	m.historyPath = config.History
+	m.keys = NewKeyMap(config.Keys)
+	m.theme = NewTheme(config)
+	m.help = newHelp(m.theme)
+	m.pollInterval = config.Durations.Poll.Duration
+	m.estimates = config.Estimates()
+	m.pomodoro = config.Pomodoro
+	m.notify = config.Notify
+	m.tracking = config.Tracking
+	m.hooks = NewHooks(config.Hooks)
+	m.goal = config.Goal
+	m = m.loadFocus()
+	m.spinner.Style = lipgloss.NewStyle().Foreground(m.theme.Accent)
+	m.list = newTodoList(m.keys, m.theme)
+	m.filters = savedFilters(config.Filters)
+	m.filter = -1
+	return m.setItems().resize()
+}
+
+// newHelp styles the generated key help in the theme's muted colors
+func newHelp(theme Theme) help.Model {
+	h := help.New()
+	keyStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	descStyle := lipgloss.NewStyle().Foreground(theme.Subtle)
+	h.Styles.ShortKey = keyStyle
+	h.Styles.FullKey = keyStyle
+	h.Styles.ShortDesc = descStyle
+	h.Styles.FullDesc = descStyle
+	h.Styles.ShortSeparator = descStyle
+	h.Styles.FullSeparator = descStyle
+	h.Styles.Ellipsis = descStyle
+	return h
+}
+
+// reload reads the file again without reconciling, for when another process
+// (like the daemon) has just written the times we should show
+func (m TodoSelectorModel) reload() TodoSelectorModel {
+	if todos, err := ReadTodosWithEstimates(m.filename, m.estimates); err == nil {
+		m.todos = sortTodos(todos)
+		m = m.setItems()
+	}
+	if stat, err := os.Stat(m.filename); err == nil {
+		m.lastModified = stat.ModTime()
+	}
+	// The daemon records its sessions in the history itself
+	return m.loadFocus()
+}
+
+// loadFocus reads the history for the daily goal, when there is one
+func (m TodoSelectorModel) loadFocus() TodoSelectorModel {
+	if !m.goal.Enabled() {
+		return m
+	}
+	if focus, err := LoadFocusLog(m.historyPath); err == nil {
+		m.focus = focus
+	} else {
+		return m.fail(fmt.Errorf("failed to read the history for the goal: %w", err))
 	}
+	return m
 }
 
 func (m TodoSelectorModel) Init() tea.Cmd {
@@ -86,7 +255,7 @@ func (m TodoSelectorModel) Init() tea.Cmd {
 }
 
 func (m TodoSelectorModel) checkFile() tea.Cmd {
-	return tea.Tick(time.Second*2, func(t time.Time) tea.Msg {
+	return tea.Tick(m.pollInterval, func(t time.Time) tea.Msg {
 		return checkFileMsg{}
 	})
 }
@@ -95,23 +264,41 @@ func (m TodoSelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	
 	switch msg := msg.(type) {
+	case ShutdownMsg:
+		return m, tea.Quit
+		
+	case tea.WindowSizeMsg:
+		return m.setSize(msg.Width, msg.Height), nil
+		
 	case tea.KeyMsg:
-		switch keypress := msg.String(); keypress {
-		case "ctrl+c", "q":
+		m.err = nil
+		if m.recovered != nil {
+			return m.updateRecovery(msg)
+		}
+		// While a search is typed every key goes to it
+		if m.list.SettingFilter() {
+			break
+		}
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
-		case "down", "j":
-			if m.cursor < len(m.todos)-1 {
-				m.cursor++
+		case key.Matches(msg, m.keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+			return m.resize(), nil
+		case key.Matches(msg, m.keys.Filter):
+			return m.nextFilter().resize(), nil
+		case key.Matches(msg, m.keys.Start):
+			if index, ok := m.selected(); ok {
+				timerModel := NewBubblesTimer(&m.todos[index], m, index)
+				return timerModel, timerModel.Init()
 			}
-		case "enter":
-			if m.cursor < len(m.todos) {
-				timerModel := NewBubblesTimer(&m.todos[m.cursor], m, m.cursor)
+			return m, nil
+		case key.Matches(msg, m.keys.Stopwatch):
+			if index, ok := m.selected(); ok {
+				timerModel := NewBubblesStopwatch(&m.todos[index], m, index)
 				return timerModel, timerModel.Init()
 			}
+			return m, nil
 		}
 		
 	case checkFileMsg:
@@ -128,22 +315,20 @@ func (m TodoSelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		
 	case fileChangedMsg:
 		m.loading = false
+		now := time.Now()
+		m.hooks.Run(Event{Type: EventReloaded, Time: now, File: absPath(m.filename)})
 		// Reload todos from file
-		if newTodos, err := ReadTodos(m.filename); err == nil {
+		if newTodos, err := ReadTodosWithEstimates(m.filename, m.estimates); err == nil {
+			m.bus.Publish(FileReloaded{Time: now, File: absPath(m.filename), Todos: newTodos})
 			// Reconcile old todos with new ones
 			reconciledTodos := ReconcileTodos(m.todos, newTodos)
 			// Sort todos (completed items last)
 			sortedTodos := sortTodos(reconciledTodos)
 			m.todos = sortedTodos
-			
-			// Keep cursor within bounds
-			if m.cursor >= len(m.todos) {
-				m.cursor = len(m.todos) - 1
-			}
-			if m.cursor < 0 {
-				m.cursor = 0
-			}
+			m = m.setItems()
 		}
+		// Time recorded outside the TUI changes the file and the history
+		m = m.loadFocus()
 		return m, m.checkFile()
 		
 	case spinner.TickMsg:
@@ -152,6 +337,15 @@ func (m TodoSelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 	
+	// Moving, searching and the search's own messages are the list's
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	if _, ok := msg.(tea.KeyMsg); ok {
+		// Starting or finishing a search changes the help
+		m = m.resize()
+	}
+	
 	return m, tea.Batch(cmds...)
 }
 
@@ -159,163 +353,268 @@ func (m TodoSelectorModel) View() string {
 	if m.loading {
 		return fmt.Sprintf("\n   %s Loading todos...\n\n", m.spinner.View())
 	}
+	if m.recovered != nil {
+		return m.recoveryView()
+	}
 	
 	var s strings.Builder
 	
-	// Title
-	titleStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#FAFAFA")).
-		Background(lipgloss.Color("#7D56F4")).
-		Padding(0, 1)
-	
-	s.WriteString(titleStyle.Render("📝 TODO Selector"))
-	s.WriteString("\n\n")
-	
-	// Show todos in markdown style
-	for i, todo := range m.todos {
-		var checkbox string
-		switch todo.State {
-		case Done:
-			checkbox = "[x]"
-		default:
-			if todo.TimeSpent > 0 {
-				checkbox = "[*]"
-			} else {
-				checkbox = "[ ]"
-			}
-		}
-		
-		// Create the todo line
-		todoLine := fmt.Sprintf("- %s %s", checkbox, todo.Description)
-		
-		// Style for cursor selection
-		if i == m.cursor {
-			selectedStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#01BE85")).
-				Bold(true)
-			s.WriteString("> " + selectedStyle.Render(todoLine))
-		} else {
-			s.WriteString("  " + todoLine)
-		}
-		
-		s.WriteString("\n")
-		
-		// Add time info on next line if available
-		if todo.TimeSpent > 0 {
-			timeInfo := fmt.Sprintf("    spent: %v", todo.TimeSpent.Round(time.Minute))
-			if i == m.cursor {
-				selectedStyle := lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#01BE85"))
-				s.WriteString("  " + selectedStyle.Render(timeInfo))
-			} else {
-				timeStyle := lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#888888"))
-				s.WriteString("  " + timeStyle.Render(timeInfo))
-			}
-			s.WriteString("\n")
-		}
-	}
+	s.WriteString(m.headerView())
+	s.WriteString(m.list.View())
 	
 	// Help text
-	s.WriteString("\n")
-	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#555555"))
-	s.WriteString(helpStyle.Render("↑/↓ or j/k: navigate • enter: start timer • q: quit"))
+	s.WriteString("\n\n")
+	s.WriteString(m.helpView())
 	
 	return s.String()
 }
 
 // ===== TIMER WITH BUBBLES TIMER =====
 
+type timerPhase int
+
+const (
+	phaseWork timerPhase = iota
+	phaseShortBreak
+	phaseLongBreak
+)
+
 type TimerModel struct {
 	todo        *Todo
 	parentModel TodoSelectorModel
 	timer       timer.Model
-	startTime   time.Time
+	// stopwatch counts up instead for todos without an estimate
+	stopwatch   stopwatch.Model
+	// session tracks the time worked on a locally timed todo, leaving out
+	// pauses
+	session     ActiveSession
 	todoIndex   int
+	phase       timerPhase
+	breakLength time.Duration
+	// confirmingQuit is set while asking whether to quit
+	confirmingQuit bool
+	// Set while the session is owned by a daemon
+	active      *ActiveSession
+	events      <-chan Event
+	unsubscribe func()
+	// err is the last failure to show: a request the daemon turned down or
+	// a notification that couldn't be sent
+	err error
 }
 
 func NewBubblesTimer(todo *Todo, parent TodoSelectorModel, todoIndex int) TimerModel {
-	t := timer.NewWithInterval(todo.EstimatedTime, time.Second)
-	
-	return TimerModel{
+	if !todo.HasEstimate() {
+		return NewBubblesStopwatch(todo, parent, todoIndex)
+	}
+	if parent.daemon != nil {
+		if m, err := newDaemonTimer(todo, parent, todoIndex); err == nil {
+			return m
+		}
+		// Fall back to timing locally if the daemon went away
+	}
+	return startLocal(todo, parent, todoIndex, todo.EstimatedTime)
+}
+
+// NewBubblesStopwatch times a todo counting up, with no estimate to run
+// out. Stopwatches are always timed by the TUI itself.
+func NewBubblesStopwatch(todo *Todo, parent TodoSelectorModel, todoIndex int) TimerModel {
+	return startLocal(todo, parent, todoIndex, 0)
+}
+
+// startLocal starts a session timed in this process
+func startLocal(todo *Todo, parent TodoSelectorModel, todoIndex int, estimate time.Duration) TimerModel {
+	now := time.Now()
+	m := newLocalTimer(todo, parent, todoIndex, ActiveSession{
+		File:        absPath(parent.filename),
+		Description: todo.Description,
+		Line:        todo.LineNumber,
+		Estimate:    estimate,
+		Started:     now,
+		Resumed:     now,
+	})
+	m = m.saveState()
+	m.runHook(EventStarted, nil)
+	if parent.switchedFrom != nil {
+		active := m.session
+		m.emit(Event{Type: EventSwitched, Time: time.Now(), Active: &active, Session: parent.switchedFrom})
+		m.parentModel.switchedFrom = nil
+	}
+	return m
+}
+
+// newLocalTimer times session in this process, counting down what is left
+// of its estimate or, without one, counting up
+func newLocalTimer(todo *Todo, parent TodoSelectorModel, todoIndex int, session ActiveSession) TimerModel {
+	m := TimerModel{
 		todo:        todo,
 		parentModel: parent,
-		timer:       t,
-		startTime:   time.Now(),
+		session:     session,
 		todoIndex:   todoIndex,
 	}
+	if session.Estimate > 0 {
+		m.timer = timer.NewWithInterval(session.Remaining().Round(time.Second), time.Second)
+	} else {
+		m.stopwatch = stopwatch.NewWithInterval(time.Second)
+	}
+	return m
+}
+
+// newDaemonTimer starts (or picks up) the session in the daemon and follows
+// its events, so pausing or stopping from another client shows up here
+func newDaemonTimer(todo *Todo, parent TodoSelectorModel, todoIndex int) (TimerModel, error) {
+	active, err := parent.daemon.Status()
+	if err != nil {
+		return TimerModel{}, err
+	}
+	sameTodo := active != nil && absPath(active.File) == absPath(parent.filename) &&
+		active.Line == todo.LineNumber
+	if !sameTodo {
+		if active, err = parent.daemon.Switch(parent.filename, strconv.Itoa(todo.LineNumber)); err != nil {
+			return TimerModel{}, err
+		}
+	}
+
+	events, unsubscribe, err := parent.daemon.Subscribe()
+	if err != nil {
+		return TimerModel{}, err
+	}
+
+	return TimerModel{
+		todo:        todo,
+		parentModel: parent,
+		timer:       timer.NewWithInterval(active.Remaining().Round(time.Second), time.Second),
+		todoIndex:   todoIndex,
+		active:      active,
+		events:      events,
+		unsubscribe: unsubscribe,
+	}, nil
 }
 
 func (m TimerModel) Init() tea.Cmd {
+	if m.active != nil {
+		cmds := []tea.Cmd{m.timer.Init(), waitForEvent(m.events)}
+		if m.active.Paused {
+			cmds = append(cmds, m.timer.Stop())
+		}
+		return tea.Batch(cmds...)
+	}
+	if m.counting() {
+		return m.stopwatch.Init()
+	}
 	return m.timer.Init()
 }
 
+func waitForEvent(events <-chan Event) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		return daemonEventMsg{event: event, closed: !ok}
+	}
+}
+
 func (m TimerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(ShutdownMsg); ok {
+		return m.quit()
+	}
+	if failed, ok := msg.(notifyFailedMsg); ok {
+		m.err = fmt.Errorf("notification failed: %w", failed.err)
+		return m, nil
+	}
+	// Keep the list fitted to the terminal for when the timer is left
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.parentModel = m.parentModel.setSize(size.Width, size.Height)
+		return m, nil
+	}
+	if m.phase != phaseWork {
+		return m.updateBreak(msg)
+	}
+	if m.active != nil {
+		return m.updateDaemon(msg)
+	}
+	if m.counting() {
+		return m.updateStopwatch(msg)
+	}
+
+	keys := m.parentModel.keys
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
-		case " ":
+		if m.confirmingQuit {
+			return m.answerQuit(msg)
+		}
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m.requestQuit()
+		case key.Matches(msg, keys.Help):
+			m.parentModel.help.ShowAll = !m.parentModel.help.ShowAll
+			return m, nil
+		case key.Matches(msg, keys.Pause):
+			if m.timer.Timedout() {
+				return m.toggleOvertime()
+			}
 			if m.timer.Running() {
+				m.session.Pause()
+				m = m.saveState()
+				m.runHook(EventPaused, nil)
 				return m, m.timer.Stop()
 			} else {
+				m.session.Resume()
+				m = m.saveState()
+				m.runHook(EventResumed, nil)
 				return m, m.timer.Start()
 			}
-		case "h":
-			// Calculate elapsed time since timer started
-			elapsed := time.Since(m.startTime)
-			if elapsed > 0 && m.todoIndex < len(m.parentModel.todos) {
-				m.parentModel.todos[m.todoIndex].AddTime(elapsed)
-			}
-			
-			// Write updated todos back to file
-			if err := WriteTodos(m.parentModel.filename, m.parentModel.todos); err != nil {
-				// Handle error silently for now
-			}
-			
-			return m.parentModel, m.parentModel.checkFile()
-		case "d":
-			// Calculate elapsed time since timer started
-			elapsed := time.Since(m.startTime)
-			if elapsed > 0 && m.todoIndex < len(m.parentModel.todos) {
-				m.parentModel.todos[m.todoIndex].AddTime(elapsed)
-				m.parentModel.todos[m.todoIndex].MarkDone()
-			}
-			
-			// Write updated todos back to file
-			if err := WriteTodos(m.parentModel.filename, m.parentModel.todos); err != nil {
-				// Handle error silently for now
-			}
-			
-			return m.parentModel, m.parentModel.checkFile()
-		case "y":
+		case key.Matches(msg, keys.Switch):
+			return m.switchTodo()
+		case key.Matches(msg, keys.Done):
+			return m.leave(true)
+		case key.Matches(msg, keys.Yes):
 			if m.timer.Timedout() {
-				newTimer := timer.NewWithInterval(m.todo.EstimatedTime, time.Second)
-				m.timer = newTimer
-				m.startTime = time.Now()
-				return m, m.timer.Init()
+				// The interval is recorded with its overtime before the
+				// next one starts
+				saved, _, err := m.saveWork(false)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				return saved.nextWork()
 			}
-		case "n":
+		case key.Matches(msg, keys.No):
 			if m.timer.Timedout() {
-				return m.parentModel, m.parentModel.checkFile()
+				return m.leave(false)
 			}
 		}
 		
 	case timer.TickMsg:
+		m = m.checkpoint()
 		var cmd tea.Cmd
 		m.timer, cmd = m.timer.Update(msg)
 		return m, cmd
 		
+	case overtimeTickMsg:
+		if msg.id != m.timer.ID() {
+			return m, nil
+		}
+		m = m.checkpoint()
+		return m, m.tickOvertime()
+		
 	case timer.StartStopMsg:
 		var cmd tea.Cmd
 		m.timer, cmd = m.timer.Update(msg)
 		return m, cmd
 		
 	case timer.TimeoutMsg:
-		return m, nil
+		if msg.ID != m.timer.ID() {
+			return m, nil
+		}
+		m.runHook(EventTimeout, nil)
+		if m.parentModel.pomodoro.Enabled {
+			return m.finishWork()
+		}
+		if !m.parentModel.tracking.Overtime {
+			// Waiting for an answer isn't work
+			m.session.Pause()
+			m = m.saveState()
+			return m, m.notifyWorkEnd("Time's up")
+		}
+		return m, tea.Batch(m.notifyWorkEnd("Time's up"), m.tickOvertime())
 	}
 	
 	var cmd tea.Cmd
@@ -323,104 +622,664 @@ func (m TimerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// updateDaemon handles input while the daemon owns the session. Time is
+// recorded by the daemon, so leaving the TUI does not stop the timer.
+func (m TimerModel) updateDaemon(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keys := m.parentModel.keys
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Help):
+			m.parentModel.help.ShowAll = !m.parentModel.help.ShowAll
+			return m, nil
+		case key.Matches(msg, keys.Pause):
+			// The timer follows the daemon's paused/resumed event
+			var err error
+			if m.active.Paused {
+				_, err = m.parentModel.daemon.Resume()
+			} else {
+				_, err = m.parentModel.daemon.Pause()
+			}
+			m.err = err
+			return m, nil
+		case key.Matches(msg, keys.Switch):
+			return m.stopDaemon(false)
+		case key.Matches(msg, keys.Done):
+			return m.stopDaemon(true)
+		case key.Matches(msg, keys.Yes):
+			if m.timer.Timedout() {
+				return m.nextDaemonWork()
+			}
+		case key.Matches(msg, keys.No):
+			if m.timer.Timedout() {
+				return m.stopDaemon(false)
+			}
+		}
+		return m, nil
+
+	case daemonEventMsg:
+		if msg.closed {
+			return m.leaveDaemon()
+		}
+		event := msg.event
+		switch event.Type {
+		case EventPaused, EventResumed:
+			m.active = event.Active
+			m.timer.Timeout = m.active.Remaining().Round(time.Second)
+			if m.active.Paused {
+				return m, tea.Batch(m.timer.Stop(), waitForEvent(m.events))
+			}
+			return m, tea.Batch(m.timer.Start(), waitForEvent(m.events))
+		case EventStopped, EventDone:
+			// Another client ended this session. Events about other todos,
+			// and about intervals this timer has moved on from, are ignored.
+			if event.Session != nil && m.follows(event.Session.File, event.Session.Start) {
+				return m.leaveDaemon()
+			}
+		case EventDiscarded:
+			if event.Active != nil && m.follows(event.Active.File, event.Active.Started) {
+				return m.leaveDaemon()
+			}
+		}
+		return m, waitForEvent(m.events)
+
+	case timer.TimeoutMsg:
+		if msg.ID != m.timer.ID() {
+			return m, nil
+		}
+		if m.parentModel.pomodoro.Enabled {
+			return m.finishWork()
+		}
+		if !m.parentModel.tracking.Overtime {
+			// Waiting for an answer isn't work, so the daemon stops
+			// counting until it comes
+			active, err := m.parentModel.daemon.Pause()
+			if err != nil {
+				m.err = err
+			} else {
+				m.active = active
+			}
+			return m, m.notifyWorkEnd("Time's up")
+		}
+		return m, tea.Batch(m.notifyWorkEnd("Time's up"), m.tickOvertime())
+
+	case overtimeTickMsg:
+		if msg.id != m.timer.ID() {
+			return m, nil
+		}
+		return m, m.tickOvertime()
+	}
+
+	var cmd tea.Cmd
+	m.timer, cmd = m.timer.Update(msg)
+	return m, cmd
+}
+
+// follows reports whether the session of a file that started at the given
+// time is the one this timer shows
+func (m TimerModel) follows(file string, started time.Time) bool {
+	return absPath(file) == absPath(m.active.File) && started.Equal(m.active.Started)
+}
+
+// stopDaemon records the session in the daemon and returns to the selector.
+// If the daemon fails to, the timer stays up showing why, as no time has
+// been recorded.
+func (m TimerModel) stopDaemon(markDone bool) (tea.Model, tea.Cmd) {
+	if _, err := m.parentModel.daemon.Stop(markDone); err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m.leaveDaemon()
+}
+
+// nextDaemonWork records the interval that ran out and has the daemon time
+// another, as the local timer does, so the daemon counts down the extension
+func (m TimerModel) nextDaemonWork() (tea.Model, tea.Cmd) {
+	active, err := m.parentModel.daemon.Switch(m.parentModel.filename, strconv.Itoa(m.active.Line))
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.err = nil
+	m.active = active
+	m.timer = timer.NewWithInterval(active.Remaining().Round(time.Second), time.Second)
+	return m, m.timer.Init()
+}
+
+// leaveDaemon stops following the daemon and returns to the selector with
+// the times the daemon wrote
+func (m TimerModel) leaveDaemon() (tea.Model, tea.Cmd) {
+	m.unsubscribe()
+	parent := m.parentModel.reload()
+	return parent, parent.checkFile()
+}
+
+// updateBreak handles input during a break. The work interval was recorded
+// when it ended, so nothing here adds time to the todo.
+func (m TimerModel) updateBreak(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keys := m.breakKeys()
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Help):
+			m.parentModel.help.ShowAll = !m.parentModel.help.ShowAll
+		case key.Matches(msg, keys.Pause):
+			if !m.timer.Timedout() {
+				return m, m.timer.Toggle()
+			}
+		case key.Matches(msg, keys.Skip):
+			if m.canSkip() {
+				return m.nextWork()
+			}
+		case key.Matches(msg, keys.Switch):
+			if m.canSkip() {
+				return m.parentModel, m.parentModel.checkFile()
+			}
+		case key.Matches(msg, keys.Done):
+			if m.canSkip() {
+				parent := m.markDone()
+				return parent, parent.checkFile()
+			}
+		case key.Matches(msg, keys.Yes):
+			if m.timer.Timedout() {
+				return m.nextWork()
+			}
+		case key.Matches(msg, keys.No):
+			if m.timer.Timedout() {
+				return m.parentModel, m.parentModel.checkFile()
+			}
+		}
+		return m, nil
+
+	case daemonEventMsg:
+		// Left over from the work interval; the daemon isn't followed during breaks
+		return m, nil
+
+	case timer.TimeoutMsg:
+		if msg.ID != m.timer.ID() {
+			return m, nil
+		}
+		var notify tea.Cmd
+		if m.parentModel.notify.BreakEnd {
+			notify = m.parentModel.notify.notify(m.parentModel.terminal, "Break's over", "Back to "+m.todo.Description)
+		}
+		if m.parentModel.pomodoro.AutoStartWork {
+			next, cmd := m.nextWork()
+			return next, tea.Batch(cmd, notify)
+		}
+		return m, notify
+	}
+
+	var cmd tea.Cmd
+	m.timer, cmd = m.timer.Update(msg)
+	return m, cmd
+}
+
+// finishWork records the work interval that just ran out and starts a
+// break, a long one at the end of each cycle
+func (m TimerModel) finishWork() (tea.Model, tea.Cmd) {
+	if m.active != nil {
+		// Without the interval recorded there is no break to take
+		if _, err := m.parentModel.daemon.Stop(false); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.unsubscribe()
+		m.active, m.events, m.unsubscribe = nil, nil, nil
+		m = m.relocate()
+	} else {
+		saved, _, err := m.saveWork(false)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m = saved
+	}
+
+	pomodoro := m.parentModel.pomodoro
+	m.parentModel.pomodoros++
+	m.phase = phaseShortBreak
+	length := pomodoro.ShortBreak.Duration
+	if m.parentModel.pomodoros%pomodoro.LongBreakEvery == 0 {
+		m.phase = phaseLongBreak
+		length = pomodoro.LongBreak.Duration
+	}
+	m.timer = timer.NewWithInterval(length, time.Second)
+	m.breakLength = length
+	title := "Time for a break"
+	if m.phase == phaseLongBreak {
+		title = "Time for a long break"
+	}
+	return m, tea.Batch(m.timer.Init(), m.notifyWorkEnd(title))
+}
+
+// notifyWorkEnd tells the user the work interval ran out, if they asked to
+// be told
+func (m TimerModel) notifyWorkEnd(title string) tea.Cmd {
+	if !m.parentModel.notify.WorkEnd {
+		return nil
+	}
+	return m.parentModel.notify.notify(m.parentModel.terminal, title, "Finished "+m.todo.Description)
+}
+
+// breakElapsed is how much of the break has been taken, not counting pauses
+func (m TimerModel) breakElapsed() time.Duration {
+	return m.breakLength - m.timer.Timeout
+}
+
+// canSkip reports whether the break may be cut short, which also covers
+// leaving it to switch tasks or mark the todo done
+func (m TimerModel) canSkip() bool {
+	if m.timer.Timedout() {
+		return true
+	}
+	pomodoro := m.parentModel.pomodoro
+	switch pomodoro.BreakMode {
+	case BreakStrict:
+		return false
+	case BreakDelayed:
+		return m.breakElapsed() >= pomodoro.SkipAfter.Duration
+	}
+	return true
+}
+
+// breakKeys disables the bindings the break mode doesn't allow yet, which
+// also hides them from the help
+func (m TimerModel) breakKeys() KeyMap {
+	keys := m.parentModel.keys
+	allowed := m.canSkip()
+	keys.Skip.SetEnabled(allowed)
+	keys.Switch.SetEnabled(allowed)
+	keys.Done.SetEnabled(allowed)
+	return keys
+}
+
+// nextWork starts another work interval on the same todo
+func (m TimerModel) nextWork() (tea.Model, tea.Cmd) {
+	next := NewBubblesTimer(m.todo, m.parentModel, m.todoIndex)
+	return next, next.Init()
+}
+
+// saveWork adds the time worked to the todo, writes the file and records
+// the session. Like autosave, it works on the file as it is now, so edits
+// made elsewhere during the session are kept. If the file can't be written
+// nothing is recorded and the error is returned, so the timer can stay up
+// with the session still in the state file. Failures after that are left
+// on the selector to show. The recorded session is returned, or nil if no
+// time was worked.
+func (m TimerModel) saveWork(markDone bool) (TimerModel, *Session, error) {
+	elapsed := m.session.Elapsed()
+	worked := elapsed > 0
+	var todo Todo
+	if worked {
+		todos, err := ReadTodosWithEstimates(m.parentModel.filename, m.parentModel.estimates)
+		if err != nil {
+			return m, nil, fmt.Errorf("failed to record the time: %w", err)
+		}
+		index := MatchTodo(todos, m.session.Line, m.session.Description)
+		if index < 0 {
+			return m, nil, fmt.Errorf("failed to record the time: %q is no longer in %s", m.session.Description, m.parentModel.filename)
+		}
+		todos[index].AddTime(elapsed - m.session.Saved)
+		if markDone {
+			todos[index].MarkDone()
+		}
+		if err := WriteTodos(m.parentModel.filename, todos); err != nil {
+			return m, nil, fmt.Errorf("failed to record the time: %w", err)
+		}
+		todo = todos[index]
+		m.parentModel.bus.Publish(TodoChanged{Time: time.Now(), File: absPath(m.parentModel.filename), Todo: todo})
+		// Follow the todo to where it is in the file now
+		m.todo = &todo
+		m = m.relocate()
+	}
+	
+	var errs []error
+	if err := ClearActiveSession(m.parentModel.statePath); err != nil {
+		errs = append(errs, fmt.Errorf("failed to clear the timer state: %w", err))
+	}
+	
+	// Hooks run once the file is written, so they can read the new time
+	var session *Session
+	if worked {
+		recorded, err := m.recordSession(todo, elapsed, markDone)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		eventType := EventStopped
+		if markDone {
+			eventType = EventDone
+		}
+		m.runHook(eventType, &recorded)
+		session = &recorded
+	}
+	if len(errs) > 0 {
+		m.parentModel = m.parentModel.fail(errors.Join(errs...))
+	}
+	return m, session, nil
+}
+
+// leave records the session and returns to the selector, or stays up
+// showing why the time couldn't be recorded
+func (m TimerModel) leave(markDone bool) (tea.Model, tea.Cmd) {
+	m, _, err := m.saveWork(markDone)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m.parentModel, m.parentModel.checkFile()
+}
+
+// switchTodo records the session and returns to the selector to pick the
+// next todo, which gets the switched event once it starts
+func (m TimerModel) switchTodo() (tea.Model, tea.Cmd) {
+	m, session, err := m.saveWork(false)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.parentModel.switchedFrom = session
+	return m.parentModel, m.parentModel.checkFile()
+}
+
+// checkpoint autosaves the session when an autosave interval has passed,
+// or else saves it to the state file every checkpointInterval
+func (m TimerModel) checkpoint() TimerModel {
+	if autosave := m.parentModel.tracking.Autosave.Duration; autosave > 0 &&
+		m.session.Elapsed()-m.session.Saved >= autosave {
+		return m.autosave()
+	}
+	if time.Since(m.session.Checkpoint) >= checkpointInterval {
+		return m.saveState()
+	}
+	return m
+}
+
+// tickOvertime keeps the overtime count going once the countdown is over
+func (m TimerModel) tickOvertime() tea.Cmd {
+	id := m.timer.ID()
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return overtimeTickMsg{id: id}
+	})
+}
+
+// toggleOvertime pauses or resumes counting overtime. Without overtime the
+// session is already paused and waiting for an answer.
+func (m TimerModel) toggleOvertime() (tea.Model, tea.Cmd) {
+	if !m.parentModel.tracking.Overtime {
+		return m, nil
+	}
+	if m.session.Paused {
+		m.session.Resume()
+		m = m.saveState()
+		m.runHook(EventResumed, nil)
+	} else {
+		m.session.Pause()
+		m = m.saveState()
+		m.runHook(EventPaused, nil)
+	}
+	return m, nil
+}
+
+// autosave writes the whole minutes worked since the last save into the
+// file while the session goes on. Like the engine, it adds them to the file
+// as it is now rather than to the todos loaded when the timer started, so
+// edits made elsewhere meanwhile are kept. The todo only changes once the
+// write succeeds, so a failed save is simply retried on the next tick.
+func (m TimerModel) autosave() TimerModel {
+	todos, err := ReadTodosWithEstimates(m.parentModel.filename, m.parentModel.estimates)
+	if err != nil {
+		m.err = fmt.Errorf("autosave failed: %w", err)
+		return m
+	}
+	index := MatchTodo(todos, m.session.Line, m.session.Description)
+	if index < 0 || m.todoIndex >= len(m.parentModel.todos) {
+		return m
+	}
+	unsaved := (m.session.Elapsed() - m.session.Saved).Truncate(time.Minute)
+	todos[index].AddTime(unsaved)
+	if err := WriteTodos(m.parentModel.filename, todos); err != nil {
+		m.err = fmt.Errorf("autosave failed: %w", err)
+		return m
+	}
+	m.parentModel.todos[m.todoIndex].AddTime(unsaved)
+	m.session.Saved += unsaved
+	m.parentModel.bus.Publish(TodoChanged{
+		Time: time.Now(),
+		File: absPath(m.parentModel.filename),
+		Todo: todos[index],
+	})
+	return m.saveState()
+}
+
+// requestQuit quits, or asks first if the config says to
+func (m TimerModel) requestQuit() (tea.Model, tea.Cmd) {
+	if m.parentModel.tracking.ConfirmQuit {
+		m.confirmingQuit = true
+		return m, nil
+	}
+	return m.quit()
+}
+
+// answerQuit handles the answer to requestQuit's question
+func (m TimerModel) answerQuit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	keys := m.parentModel.keys
+	switch {
+	case key.Matches(msg, keys.Yes, keys.Quit):
+		return m.quit()
+	case key.Matches(msg, keys.No):
+		m.confirmingQuit = false
+	}
+	return m, nil
+}
+
+// quit records the locally timed session before quitting. Breaks have
+// nothing left to record and the daemon keeps timing its own sessions.
+func (m TimerModel) quit() (tea.Model, tea.Cmd) {
+	if m.phase == phaseWork && m.active == nil {
+		// Time that couldn't be written stays in the state file, to be
+		// recovered next time
+		m, _, _ = m.saveWork(false)
+	}
+	return m, tea.Quit
+}
+
+// saveState checkpoints the locally timed session, so it survives the TUI
+// being killed
+func (m TimerModel) saveState() TimerModel {
+	m.session.Checkpoint = time.Now()
+	m.session.Owner = os.Getpid()
+	if err := SaveActiveSession(m.parentModel.statePath, &m.session); err != nil {
+		m.err = fmt.Errorf("failed to save the timer state: %w", err)
+	}
+	return m
+}
+
+// markDone finishes the todo without adding time, for use during a break.
+// The todo is looked up in the file as it is now, in case it has moved.
+func (m TimerModel) markDone() TodoSelectorModel {
+	todos, err := ReadTodosWithEstimates(m.parentModel.filename, m.parentModel.estimates)
+	if err != nil {
+		return m.parentModel.fail(fmt.Errorf("failed to mark the todo done: %w", err))
+	}
+	index := MatchTodo(todos, m.todo.LineNumber, m.todo.Description)
+	if index < 0 {
+		return m.parentModel.fail(fmt.Errorf("failed to mark the todo done: %q is no longer in %s", m.todo.Description, m.parentModel.filename))
+	}
+	if m.parentModel.daemon != nil {
+		_, err := m.parentModel.daemon.Done(m.parentModel.filename, strconv.Itoa(todos[index].LineNumber))
+		parent := m.parentModel.reload()
+		if err != nil {
+			return parent.fail(fmt.Errorf("failed to mark the todo done: %w", err))
+		}
+		return parent
+	}
+	todos[index].MarkDone()
+	if err := WriteTodos(m.parentModel.filename, todos); err != nil {
+		return m.parentModel.fail(fmt.Errorf("failed to mark the todo done: %w", err))
+	}
+	todo := todos[index]
+	m.parentModel.bus.Publish(TodoChanged{Time: time.Now(), File: absPath(m.parentModel.filename), Todo: todo})
+	// The interval was recorded when the break started, so there is no
+	// session to report
+	m.parentModel.hooks.Run(Event{
+		Type: EventDone,
+		Time: time.Now(),
+		File: absPath(m.parentModel.filename),
+		Todo: todo.Description,
+		Line: todo.LineNumber,
+	})
+	return m.parentModel.reload()
+}
+
+// relocate finds the todo again after the parent re-reads the file, which
+// the daemon has just written to
+func (m TimerModel) relocate() TimerModel {
+	line, description := m.todo.LineNumber, m.todo.Description
+	m.parentModel = m.parentModel.reload()
+	if index := MatchTodo(m.parentModel.todos, line, description); index >= 0 {
+		m.todoIndex = index
+		m.todo = &m.parentModel.todos[index]
+	}
+	return m
+}
+
+// recordSession appends the time just spent on todo to the history
+func (m TimerModel) recordSession(todo Todo, elapsed time.Duration, completed bool) (Session, error) {
+	// The countdown's ticks run a little behind the clock, which shouldn't
+	// count as overtime
+	overtime := overtime(elapsed, m.session.Estimate).Truncate(time.Second)
+	session := Session{
+		Description: todo.Description,
+		File:        absPath(m.parentModel.filename),
+		Line:        todo.LineNumber,
+		Start:       m.session.Started,
+		End:         time.Now(),
+		Duration:    elapsed,
+		Estimate:    m.session.Estimate,
+		Overtime:    overtime,
+		Completed:   completed,
+	}
+	m.parentModel.focus.Add(session)
+	if err := AppendSession(m.parentModel.historyPath, session); err != nil {
+		return session, fmt.Errorf("failed to add the session to the history: %w", err)
+	}
+	return session, nil
+}
+
+// runHook runs the user's hook for an event of the locally timed session
+// and publishes it on the bus. When the daemon owns the session it does
+// both instead.
+func (m TimerModel) runHook(eventType string, session *Session) {
+	event := Event{Type: eventType, Time: time.Now(), Session: session}
+	if session == nil {
+		active := m.session
+		event.Active = &active
+	}
+	m.emit(event)
+}
+
+// emit runs the user's hook for event and publishes it on the bus
+func (m TimerModel) emit(event Event) {
+	m.parentModel.hooks.Run(event)
+	m.parentModel.bus.publishSession(event)
+}
+
 func (m TimerModel) View() string {
+	if m.phase != phaseWork {
+		return m.breakView()
+	}
+	
 	var s strings.Builder
+	keys := m.parentModel.keys
+	theme := m.parentModel.theme
 	
 	// Task name with highlighting (like before)
-	taskStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#FAFAFA")).
-		Background(lipgloss.Color("#7D56F4")).
+	taskStyle := theme.Badge(theme.Accent).
 		Padding(1, 2)
 	
 	s.WriteString(taskStyle.Render(m.todo.Description))
-	s.WriteString("\n\n\n")
+	s.WriteString("\n\n")
+	if m.parentModel.pomodoro.Enabled {
+		s.WriteString(m.cycleView())
+	}
+	s.WriteString("\n")
+	if goal := m.parentModel.goalView(m.liveElapsed()); goal != "" {
+		s.WriteString(goal)
+		s.WriteString("\n\n")
+	}
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(theme.Overtime)
+		
+		s.WriteString(errorStyle.Render("⚠️ " + m.err.Error()))
+		s.WriteString("\n\n")
+	}
 	
-	if m.timer.Timedout() {
-		// Completion state
-		completeStyle := lipgloss.NewStyle().
+	if m.confirmingQuit {
+		promptStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#01BE85"))
+			Foreground(theme.Text)
 		
-		s.WriteString(completeStyle.Render("✅ COMPLETE"))
+		s.WriteString(promptStyle.Render(fmt.Sprintf("Record %v and quit?", m.session.Elapsed().Round(time.Second))))
 		s.WriteString("\n\n")
+		s.WriteString(m.parentModel.help.View(keys.quitHelp()))
+		return s.String()
+	}
+	
+	if m.counting() {
+		s.WriteString(m.stopwatchView())
+	} else if m.timer.Timedout() && m.parentModel.tracking.Overtime {
+		// Counting up past the estimate
+		session := m.current()
+		overtimeStyle := theme.Badge(theme.Overtime).
+			Padding(0, 1)
 		
-		durationMinutes := int(m.todo.EstimatedTime.Minutes())
-		
-		statusStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888"))
-		
-		s.WriteString(statusStyle.Render(fmt.Sprintf("Add another %d minutes? (y/n)", durationMinutes)))
+		s.WriteString(overtimeStyle.Render("+" + formatClock(session.Overtime())))
 		s.WriteString("\n\n")
 		
-		controlsStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#555555"))
-		
-		s.WriteString(controlsStyle.Render("d: mark done  h: switch task  q: quit"))
-	} else {
-		// Parse the timer to get minutes and seconds
-		timerText := m.timer.View()
+		statusStyle := lipgloss.NewStyle().
+			Foreground(theme.Muted)
 		
-		// Define styles for enhanced timer display
-		minutesStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#F25D94")).
-			Padding(0, 1)
+		if session.Paused {
+			s.WriteString(statusStyle.Render("⏸️ PAUSED"))
+		} else {
+			estimateMinutes := int(session.Estimate.Minutes())
+			s.WriteString(statusStyle.Render(fmt.Sprintf("over the %d minute estimate", estimateMinutes)))
+		}
 		
-		secondsStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FAFAFA"))
+		s.WriteString("\n\n")
 		
-		colonStyle := lipgloss.NewStyle().
+		s.WriteString(m.parentModel.help.View(keys.overtimeHelp()))
+	} else if m.timer.Timedout() {
+		// Completion state
+		completeStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#888888"))
+			Foreground(theme.Highlight)
 		
-		// Try to parse different timer formats
-		var minutes, seconds string
-		parsed := false
+		s.WriteString(completeStyle.Render("✅ COMPLETE"))
+		s.WriteString("\n\n")
 		
-		// Format 1: "9:30" or "09:30"
-		if strings.Contains(timerText, ":") {
-			parts := strings.Split(timerText, ":")
-			if len(parts) == 2 {
-				minutes = strings.TrimSpace(parts[0])
-				seconds = strings.TrimSpace(parts[1])
-				parsed = true
-			}
-		}
+		durationMinutes := int(m.todo.EstimatedTime.Minutes())
 		
-		// Format 2: "9m30s" or similar
-		if !parsed && strings.Contains(timerText, "m") && strings.Contains(timerText, "s") {
-			// Extract minutes and seconds from format like "9m30s"
-			text := timerText
-			if mIndex := strings.Index(text, "m"); mIndex > 0 {
-				minutes = text[:mIndex]
-				remaining := text[mIndex+1:]
-				if sIndex := strings.Index(remaining, "s"); sIndex > 0 {
-					seconds = remaining[:sIndex]
-					parsed = true
-				}
-			}
-		}
+		statusStyle := lipgloss.NewStyle().
+			Foreground(theme.Muted)
 		
-		if parsed {
-			// Create enhanced timer display with highlighted minutes
-			timerDisplay := minutesStyle.Render(minutes) + "  " + colonStyle.Render(":") + "  " + secondsStyle.Render(seconds)
-			s.WriteString(timerDisplay)
-		} else {
-			// Fallback to regular timer display if parsing fails
-			timerStyle := lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("#FAFAFA"))
-			s.WriteString(timerStyle.Render(timerText))
-		}
+		s.WriteString(statusStyle.Render(fmt.Sprintf("Add another %d minutes? (%s/%s)", durationMinutes, keys.Yes.Help().Key, keys.No.Help().Key)))
+		s.WriteString("\n\n")
 		
+		s.WriteString(m.parentModel.help.View(keys.timeoutHelp()))
+	} else {
+		s.WriteString(m.clockView(m.timer.View(), theme.Timer))
 		s.WriteString("\n\n")
 		
 		// Status line
 		statusStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888"))
+			Foreground(theme.Muted)
 		
 		if m.timer.Running() {
 			s.WriteString(statusStyle.Render("remaining"))
@@ -430,11 +1289,172 @@ func (m TimerModel) View() string {
 		
 		s.WriteString("\n\n")
 		
-		controlsStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#555555"))
+		s.WriteString(m.parentModel.help.View(keys.timerHelp()))
+	}
+	
+	return s.String()
+}
+
+// liveElapsed is the time worked in the session being timed, which the
+// history doesn't have yet
+func (m TimerModel) liveElapsed() time.Duration {
+	return m.current().Elapsed()
+}
+
+// current is the session being timed, by the daemon or by the TUI itself
+func (m TimerModel) current() *ActiveSession {
+	if m.active != nil {
+		return m.active
+	}
+	return &m.session
+}
+
+// breakView is the screen shown during breaks, in the highlight color so
+// it can't be mistaken for work time
+func (m TimerModel) breakView() string {
+	var s strings.Builder
+	keys := m.breakKeys()
+	theme := m.parentModel.theme
+	pomodoro := m.parentModel.pomodoro
+	
+	titleStyle := theme.Badge(theme.Highlight).
+		Padding(1, 2)
+	suggestionStyle := lipgloss.NewStyle().
+		Italic(true).
+		Foreground(theme.Text)
+	statusStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	
+	title := "☕ Short break"
+	if m.phase == phaseLongBreak {
+		title = "🌴 Long break"
+	}
+	s.WriteString(titleStyle.Render(title))
+	s.WriteString("\n\n")
+	s.WriteString(m.cycleView())
+	s.WriteString("\n\n")
+	// The interval just finished is in the history already
+	if goal := m.parentModel.goalView(0); goal != "" {
+		s.WriteString(goal)
+		s.WriteString("\n\n")
+	}
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(theme.Overtime)
 		
-		s.WriteString(controlsStyle.Render("space: resume  h: switch  d: done  q: quit"))
+		s.WriteString(errorStyle.Render("⚠️ " + m.err.Error()))
+		s.WriteString("\n\n")
+	}
+	
+	if len(pomodoro.Suggestions) > 0 {
+		suggestion := pomodoro.Suggestions[(m.parentModel.pomodoros-1)%len(pomodoro.Suggestions)]
+		s.WriteString(suggestionStyle.Render(suggestion))
+		s.WriteString("\n\n")
 	}
 	
+	if m.timer.Timedout() {
+		s.WriteString(statusStyle.Render(fmt.Sprintf("Break's over. Back to %q? (%s/%s)", m.todo.Description, keys.Yes.Help().Key, keys.No.Help().Key)))
+		s.WriteString("\n\n")
+		s.WriteString(m.parentModel.help.View(keys.timeoutHelp()))
+		return s.String()
+	}
+	
+	s.WriteString(m.clockView(m.timer.View(), theme.Highlight))
+	s.WriteString("\n\n")
+	if m.timer.Running() {
+		s.WriteString(statusStyle.Render(fmt.Sprintf("then back to %q", m.todo.Description)))
+	} else {
+		s.WriteString(statusStyle.Render("⏸️ PAUSED"))
+	}
+	s.WriteString("\n")
+	
+	switch {
+	case pomodoro.BreakMode == BreakStrict:
+		s.WriteString(statusStyle.Render("This break can't be skipped"))
+	case !m.canSkip():
+		wait := pomodoro.SkipAfter.Duration - m.breakElapsed()
+		s.WriteString(statusStyle.Render(fmt.Sprintf("Can be skipped in %s", formatClock(wait))))
+	}
+	s.WriteString("\n\n")
+	s.WriteString(m.parentModel.help.View(keys.breakHelp()))
 	return s.String()
-}
\ No newline at end of file
+}
+
+// cycleView shows the pomodoros done in the current cycle, like "●●○○"
+func (m TimerModel) cycleView() string {
+	theme := m.parentModel.theme
+	every := m.parentModel.pomodoro.LongBreakEvery
+	done := m.parentModel.pomodoros % every
+	if m.phase == phaseLongBreak {
+		// The cycle that just ended stays full until the break is over
+		done = every
+	}
+	
+	marks := lipgloss.NewStyle().Foreground(theme.Timer).Render(strings.Repeat("●", done)) +
+		lipgloss.NewStyle().Foreground(theme.Subtle).Render(strings.Repeat("○", every-done))
+	noun := "pomodoros"
+	if m.parentModel.pomodoros == 1 {
+		noun = "pomodoro"
+	}
+	count := lipgloss.NewStyle().Foreground(theme.Muted).
+		Render(fmt.Sprintf("  %d %s", m.parentModel.pomodoros, noun))
+	return marks + count
+}
+
+// clockView renders a clock like "9:30" with the minutes on a colored badge
+func (m TimerModel) clockView(timerText string, background lipgloss.TerminalColor) string {
+	theme := m.parentModel.theme
+	
+	// Parse the timer to get minutes and seconds
+	
+	// Define styles for enhanced timer display
+	minutesStyle := theme.Badge(background).
+		Padding(0, 1)
+	
+	secondsStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Text)
+	
+	colonStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Muted)
+	
+	// Try to parse different timer formats
+	var minutes, seconds string
+	parsed := false
+	
+	// Format 1: "9:30" or "09:30"
+	if strings.Contains(timerText, ":") {
+		parts := strings.Split(timerText, ":")
+		if len(parts) == 2 {
+			minutes = strings.TrimSpace(parts[0])
+			seconds = strings.TrimSpace(parts[1])
+			parsed = true
+		}
+	}
+	
+	// Format 2: "9m30s" or similar
+	if !parsed && strings.Contains(timerText, "m") && strings.Contains(timerText, "s") {
+		// Extract minutes and seconds from format like "9m30s"
+		text := timerText
+		if mIndex := strings.Index(text, "m"); mIndex > 0 {
+			minutes = text[:mIndex]
+			remaining := text[mIndex+1:]
+			if sIndex := strings.Index(remaining, "s"); sIndex > 0 {
+				seconds = remaining[:sIndex]
+				parsed = true
+			}
+		}
+	}
+	
+	if parsed {
+		// Create enhanced timer display with highlighted minutes
+		return minutesStyle.Render(minutes) + "  " + colonStyle.Render(":") + "  " + secondsStyle.Render(seconds)
+	}
+	
+	// Fallback to regular timer display if parsing fails
+	timerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Text)
+	return timerStyle.Render(timerText)
+}
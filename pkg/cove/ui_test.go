@@ -0,0 +1,343 @@
+package cove
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/timer"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestSelector returns a selector over engineTodos, keeping the TUI's
+// state and history in a temporary directory
+func newTestSelector(t *testing.T) (TodoSelectorModel, string) {
+	t.Helper()
+	isolate(t)
+	path := writeFile(t, "todos.md", engineTodos)
+	todos, err := ReadTodos(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTodoSelector(todos, path), path
+}
+
+// press sends the key bound to the given runes
+func press(m tea.Model, keys string) tea.Model {
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(keys)})
+	return m
+}
+
+// togglePause presses the pause key and hands the timer its start or stop
+func togglePause(m tea.Model) tea.Model {
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if cmd != nil {
+		m, _ = m.Update(cmd())
+	}
+	return m
+}
+
+// timeOut runs the timer's countdown out
+func timeOut(m tea.Model) tea.Model {
+	timedOut := m.(TimerModel)
+	timedOut.timer.Timeout = 0
+	m, _ = timedOut.Update(timer.TimeoutMsg{ID: timedOut.timer.ID()})
+	return m
+}
+
+// worked makes the local session look like it started d earlier
+func worked(m tea.Model, d time.Duration) tea.Model {
+	local := m.(TimerModel)
+	local.session.Started = local.session.Started.Add(-d)
+	local.session.Resumed = local.session.Resumed.Add(-d)
+	return local
+}
+
+func TestTimerKeepsUnrecordedTime(t *testing.T) {
+	selector, path := newTestSelector(t)
+	var m tea.Model = NewBubblesTimer(&selector.todos[0], selector, 0)
+	m = worked(m, 5*time.Minute)
+
+	// The file can't be written while it is gone
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	m = press(m, "h")
+	timer, ok := m.(TimerModel)
+	if !ok || timer.err == nil {
+		t.Fatalf("got %T without an error on the timer", m)
+	}
+	if active, _ := LoadActiveSession(selector.statePath); active == nil {
+		t.Fatal("the unrecorded session was cleared from the state file")
+	}
+
+	if err := os.WriteFile(path, []byte(engineTodos), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if m = press(m, "h"); isTimer(m) {
+		t.Fatalf("the timer stayed: %v", m.(TimerModel).err)
+	}
+	if content := readFile(t, path); !strings.Contains(content, "- [ ] Write report ** (took 5m)\n") {
+		t.Errorf("the time wasn't recorded:\n%s", content)
+	}
+	if active, _ := LoadActiveSession(selector.statePath); active != nil {
+		t.Errorf("the recorded session is still in the state file: %+v", active)
+	}
+}
+
+func TestSelectorShowsErrors(t *testing.T) {
+	selector, _ := newTestSelector(t)
+	timer := NewBubblesTimer(&selector.todos[1], selector, 1)
+
+	// Marking done during a break fails on a file that can't be read
+	timer.phase = phaseShortBreak
+	timer.parentModel.filename = t.TempDir()
+	var m tea.Model = press(timer, "d")
+	parent, ok := m.(TodoSelectorModel)
+	if !ok || parent.err == nil {
+		t.Fatalf("got %T without an error on the selector", m)
+	}
+	if !strings.Contains(parent.View(), parent.err.Error()) {
+		t.Error("the selector doesn't show the error")
+	}
+	if m = press(m, "j"); m.(TodoSelectorModel).err != nil {
+		t.Error("the error outlived the next key")
+	}
+}
+
+func TestTimerLeavesOutPauses(t *testing.T) {
+	selector, path := newTestSelector(t)
+	var m tea.Model = NewBubblesTimer(&selector.todos[0], selector, 0)
+	m = worked(m, 10*time.Minute)
+
+	if m = togglePause(m); !m.(TimerModel).session.Paused {
+		t.Fatal("the session didn't pause")
+	}
+	// An hour away from the desk
+	m = worked(m, time.Hour)
+	if m = togglePause(m); m.(TimerModel).session.Paused {
+		t.Fatal("the session didn't resume")
+	}
+	if elapsed := m.(TimerModel).liveElapsed(); elapsed.Truncate(time.Minute) != 10*time.Minute {
+		t.Fatalf("got %v worked, want the 10 minutes before the pause", elapsed)
+	}
+
+	// Waiting to answer whether to extend isn't work either
+	local := m.(TimerModel)
+	local.parentModel.tracking.Overtime = false
+	m = timeOut(local)
+	m = worked(m, time.Hour)
+	m = press(m, "y")
+	if _, ok := m.(TimerModel); !ok {
+		t.Fatalf("got %T, want the extension's timer", m)
+	}
+	if content := readFile(t, path); !strings.Contains(content, "- [ ] Write report ** (took 10m)\n") {
+		t.Errorf("the pauses were recorded as work:\n%s", content)
+	}
+	if elapsed := m.(TimerModel).liveElapsed(); elapsed > time.Minute {
+		t.Errorf("the extension starts with %v worked", elapsed)
+	}
+}
+
+// recoverSelector returns a selector over engineTodos that finds worked
+// minutes of "Write report" left behind by a TUI that went away
+func recoverSelector(t *testing.T, worked time.Duration) (TodoSelectorModel, string) {
+	t.Helper()
+	isolate(t)
+	path := writeFile(t, "todos.md", engineTodos)
+	started := time.Now().Add(-time.Hour)
+	if err := SaveActiveSession(TUIStatePath(path), &ActiveSession{
+		File:        absPath(path),
+		Description: "Write report",
+		Line:        2,
+		Estimate:    10 * time.Minute,
+		Started:     started,
+		Resumed:     started,
+		Checkpoint:  started.Add(worked),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	todos, err := ReadTodos(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selector := NewTodoSelector(todos, path)
+	if selector.recovered == nil {
+		t.Fatal("the session wasn't recovered")
+	}
+	return selector, path
+}
+
+func TestSelectorRecovery(t *testing.T) {
+	t.Run("resume", func(t *testing.T) {
+		selector, _ := recoverSelector(t, 4*time.Minute)
+		m := press(selector, "r")
+		local, ok := m.(TimerModel)
+		if !ok || local.session.Paused || local.timer.Timedout() {
+			t.Fatalf("got %T, want the running timer", m)
+		}
+		if remaining := local.timer.Timeout; remaining != 6*time.Minute {
+			t.Errorf("got %v left, want what the estimate has after 4m", remaining)
+		}
+	})
+
+	t.Run("resume past the estimate", func(t *testing.T) {
+		selector, _ := recoverSelector(t, 15*time.Minute)
+		m, cmd := selector.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+		local, ok := m.(TimerModel)
+		if !ok || !local.timer.Timedout() || local.session.Overtime().Truncate(time.Minute) != 5*time.Minute {
+			t.Fatalf("got %T, want the timed out timer", m)
+		}
+		// The overtime keeps counting
+		if cmd == nil {
+			t.Error("nothing ticks the overtime")
+		}
+	})
+
+	t.Run("resume past the estimate without overtime", func(t *testing.T) {
+		selector, _ := recoverSelector(t, 15*time.Minute)
+		selector.tracking.Overtime = false
+		m := press(selector, "r")
+		local, ok := m.(TimerModel)
+		if !ok || !local.timer.Timedout() {
+			t.Fatalf("got %T, want the timed out timer", m)
+		}
+		// Waiting for the answer isn't work
+		if !local.session.Paused || !strings.Contains(local.View(), "Add another") {
+			t.Errorf("the timer isn't waiting to extend:\n%s", local.View())
+		}
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		selector, path := recoverSelector(t, 4*time.Minute)
+		m := press(selector, "c")
+		if parent, ok := m.(TodoSelectorModel); !ok || parent.recovered != nil || parent.err != nil {
+			t.Fatalf("got %T still recovering", m)
+		}
+		if content := readFile(t, path); !strings.Contains(content, "- [ ] Write report ** (took 4m)\n") {
+			t.Errorf("the recovered time wasn't recorded:\n%s", content)
+		}
+		if active, _ := LoadActiveSession(selector.statePath); active != nil {
+			t.Errorf("the recorded session is still in the state file: %+v", active)
+		}
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		selector, path := recoverSelector(t, 4*time.Minute)
+		m := press(selector, "x")
+		if parent, ok := m.(TodoSelectorModel); !ok || parent.recovered != nil {
+			t.Fatalf("got %T still recovering", m)
+		}
+		if content := readFile(t, path); content != engineTodos {
+			t.Errorf("discarding changed the file:\n%s", content)
+		}
+		if active, _ := LoadActiveSession(selector.statePath); active != nil {
+			t.Errorf("the discarded session is still in the state file: %+v", active)
+		}
+	})
+}
+
+func TestTimerShutdown(t *testing.T) {
+	selector, path := newTestSelector(t)
+	m := worked(NewBubblesTimer(&selector.todos[0], selector, 0), 7*time.Minute)
+
+	m, cmd := m.Update(ShutdownMsg{})
+	if cmd == nil {
+		t.Fatal("the timer didn't quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("the timer didn't quit")
+	}
+	if content := readFile(t, path); !strings.Contains(content, "- [ ] Write report ** (took 7m)\n") {
+		t.Errorf("the session wasn't recorded:\n%s", content)
+	}
+	if active, _ := LoadActiveSession(selector.statePath); active != nil {
+		t.Errorf("the recorded session is still in the state file: %+v", active)
+	}
+	sessions, err := ReadSessions(selector.historyPath)
+	if err != nil || len(sessions) != 1 || sessions[0].Duration.Truncate(time.Minute) != 7*time.Minute {
+		t.Errorf("got history %+v, %v", sessions, err)
+	}
+
+	// Breaks have nothing left to record
+	local := m.(TimerModel)
+	local.phase = phaseShortBreak
+	if _, cmd := local.Update(ShutdownMsg{}); cmd == nil {
+		t.Fatal("the break didn't quit")
+	}
+	if sessions, _ := ReadSessions(selector.historyPath); len(sessions) != 1 {
+		t.Errorf("the break recorded %d sessions", len(sessions)-1)
+	}
+}
+
+func TestTimerKeepsEditsMadeMeanwhile(t *testing.T) {
+	selector, path := newTestSelector(t)
+	selector.pomodoro.Enabled = true
+	m := worked(NewBubblesTimer(&selector.todos[0], selector, 0), 25*time.Minute)
+
+	// Another todo is added above and one is ticked off in an editor
+	edited := strings.Replace("- [ ] Added meanwhile\n"+engineTodos, "- [ ] Review PR *", "- [x] Review PR *", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m = timeOut(m)
+	local, ok := m.(TimerModel)
+	if !ok || local.phase == phaseWork {
+		t.Fatalf("got %T, want a break: %v", m, local.err)
+	}
+	if local.todo.LineNumber != 3 || local.todo.TimeSpent != 25*time.Minute {
+		t.Errorf("the timer lost track of the todo: %+v", *local.todo)
+	}
+
+	// Done during the break finds the todo where it is now
+	m = press(m, "d")
+	if _, ok := m.(TodoSelectorModel); !ok {
+		t.Fatalf("got %T, want the selector", m)
+	}
+	want := "- [ ] Added meanwhile\n# Today\n- [x] Write report ** (took 25m)\n- [x] Review PR *\n- [x] Deploy\n- [ ] Review docs\n"
+	if content := readFile(t, path); content != want {
+		t.Errorf("got file:\n%s\nwant:\n%s", content, want)
+	}
+	sessions, err := ReadSessions(selector.historyPath)
+	if err != nil || len(sessions) != 1 || sessions[0].Line != 3 {
+		t.Errorf("got history %+v, %v", sessions, err)
+	}
+}
+
+func TestStopwatch(t *testing.T) {
+	isolate(t)
+	path := writeFile(t, "todos.md", engineTodos+"- [ ] Explore est:none\n")
+	todos, err := ReadTodos(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selector := NewTodoSelector(todos, path)
+	index := MatchTodo(selector.todos, 6, "Explore est:none")
+
+	// A todo without an estimate counts up
+	var m tea.Model = NewBubblesTimer(&selector.todos[index], selector, index)
+	if !m.(TimerModel).counting() {
+		t.Fatal("the todo without an estimate got a countdown")
+	}
+	m = worked(m, 40*time.Minute)
+	if view := m.View(); !strings.Contains(view, "elapsed") || strings.Contains(view, "COMPLETE") {
+		t.Errorf("the stopwatch isn't counting up:\n%s", view)
+	}
+	if m = press(m, "h"); isTimer(m) {
+		t.Fatalf("the stopwatch stayed: %v", m.(TimerModel).err)
+	}
+	if content := readFile(t, path); !strings.Contains(content, "- [ ] Explore est:none (took 40m)\n") {
+		t.Errorf("the time wasn't recorded:\n%s", content)
+	}
+	sessions, err := ReadSessions(selector.historyPath)
+	if err != nil || len(sessions) != 1 || sessions[0].Estimate != 0 || sessions[0].Overtime != 0 {
+		t.Errorf("got history %+v, %v", sessions, err)
+	}
+
+	// The stopwatch key counts up on a todo with an estimate too
+	m = press(m, "w")
+	if local, ok := m.(TimerModel); !ok || !local.counting() || local.todo.Description != "Write report" {
+		t.Fatalf("got %T, want a stopwatch on the first todo", m)
+	}
+}
@@ -0,0 +1,122 @@
+// Mirrors the TUI: a todo selector and a big countdown, kept live through
+// the /api/events stream. The countdown ticks locally between events.
+const $ = (id) => document.getElementById(id);
+
+let status = null;
+let statusAt = 0;
+
+function clock(seconds) {
+  seconds = Math.max(0, Math.round(seconds));
+  const h = Math.floor(seconds / 3600);
+  const m = Math.floor((seconds % 3600) / 60);
+  const s = seconds % 60;
+  const mm = String(m).padStart(2, "0");
+  const ss = String(s).padStart(2, "0");
+  return h > 0 ? `${h}:${mm}:${ss}` : `${mm}:${ss}`;
+}
+
+function minutes(seconds) {
+  return `${Math.round(seconds / 60)}m`;
+}
+
+async function api(method, path, body) {
+  const response = await fetch(path, {
+    method,
+    headers: method === "POST" ? { "Content-Type": "application/json" } : {},
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.error);
+  }
+  return data;
+}
+
+function showError(err) {
+  $("error").textContent = err ? err.message : "";
+  $("error").hidden = !err;
+}
+
+async function run(method, path, body) {
+  try {
+    showError(null);
+    return await api(method, path, body);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+function setStatus(next) {
+  status = next;
+  statusAt = Date.now();
+  render();
+}
+
+function render() {
+  const timer = $("timer");
+  timer.hidden = !status;
+  if (!status) {
+    document.title = "Cove";
+    return;
+  }
+
+  const since = status.paused ? 0 : (Date.now() - statusAt) / 1000;
+  // Todos without an estimate count up like a stopwatch
+  const stopwatch = status.estimate_seconds <= 0;
+  const shown = stopwatch ? status.elapsed_seconds + since : status.remaining_seconds - since;
+
+  $("task").textContent = status.description;
+  $("clock").textContent = clock(shown);
+  $("state").textContent = status.paused ? "⏸️ PAUSED" : stopwatch ? "elapsed" : shown <= 0 ? "✅ COMPLETE" : "remaining";
+  $("toggle").textContent = status.paused ? "resume" : "pause";
+  timer.classList.toggle("paused", status.paused);
+  document.title = `${clock(shown)} ${status.description}`;
+}
+
+async function loadTodos() {
+  const todos = await run("GET", "/api/todos");
+  if (!todos) {
+    return;
+  }
+
+  // Same order as the TUI: open todos first, done ones last
+  todos.sort((a, b) => (a.state === "done") - (b.state === "done"));
+
+  const list = $("todos");
+  list.replaceChildren();
+  for (const todo of todos) {
+    const item = document.createElement("li");
+    const checkbox = todo.state === "done" ? "[x]" : todo.time_spent_seconds > 0 ? "[*]" : "[ ]";
+    item.textContent = `- ${checkbox} ${todo.description}`;
+    if (todo.time_spent_seconds > 0) {
+      const spent = document.createElement("span");
+      spent.className = "spent";
+      spent.textContent = `spent: ${minutes(todo.time_spent_seconds)}`;
+      item.append(spent);
+    }
+    item.classList.toggle("done", todo.state === "done");
+    item.classList.toggle("active", !!status && status.line === todo.line);
+    if (todo.state !== "done") {
+      item.addEventListener("click", () => run("POST", "/api/switch", { todo: String(todo.line) }));
+    }
+    list.append(item);
+  }
+}
+
+$("toggle").addEventListener("click", () => run("POST", status && status.paused ? "/api/resume" : "/api/pause"));
+$("switch").addEventListener("click", () => run("POST", "/api/stop", { done: false }));
+$("done").addEventListener("click", () => run("POST", "/api/stop", { done: true }));
+
+const events = new EventSource("/api/events");
+events.onopen = () => showError(null);
+events.onerror = () => showError(new Error("Lost connection to cove, retrying…"));
+for (const type of ["status", "started", "paused", "resumed", "stopped", "done", "discarded", "timeout", "switched", "todos"]) {
+  events.addEventListener(type, (event) => {
+    setStatus(JSON.parse(event.data).status);
+    if (type !== "paused" && type !== "resumed" && type !== "timeout") {
+      loadTodos();
+    }
+  });
+}
+
+setInterval(render, 1000);